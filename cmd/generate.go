@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/floriscornel/piak/internal/config"
 	"github.com/floriscornel/piak/internal/generator"
@@ -17,6 +20,20 @@ var (
 	namespace      string
 	generateClient bool
 	generateTests  bool
+	templatesDir   string
+	plugins        []string
+	propertyNaming string
+	readWrite      string
+	deprecatedAttr bool
+	deprecNotices  bool
+	maxDepth       int
+	int64Type      string
+	decimalType    string
+	paginationFile string
+	watchMode      bool
+	watchDebounce  time.Duration
+	dryRun         bool
+	checkMode      bool
 )
 
 // generateCmd represents the generate command.
@@ -31,7 +48,11 @@ including models, DTOs, and optionally HTTP client classes.
 Examples:
   piak generate -i api.yaml -o ./generated
   piak generate --input api.yaml --namespace "MyApp\\Models"
-  piak generate -i api.yaml -o ./generated --generate-client --generate-tests`,
+  piak generate -i api.yaml -o ./generated --generate-client --generate-tests
+  piak generate -i api.yaml -o ./generated -n "MyApp\\Api" --templates ./piak-templates
+  piak generate -i api.yaml -o ./generated -n "MyApp\\Api" --plugin rpc --plugin "di:container=symfony"
+  piak generate -i api.yaml -o ./generated -n "MyApp\\Api" --watch
+  piak generate -i api.yaml -o ./generated -n "MyApp\\Api" --check`,
 	RunE: runGenerate,
 }
 
@@ -43,6 +64,33 @@ func init() {
 	generateCmd.Flags().StringVarP(&namespace, "namespace", "n", "", "PHP namespace for generated classes (required)")
 	generateCmd.Flags().BoolVar(&generateClient, "generate-client", true, "Generate HTTP client code")
 	generateCmd.Flags().BoolVar(&generateTests, "generate-tests", true, "Generate test files")
+	generateCmd.Flags().StringVar(&templatesDir, "templates", "",
+		"Directory with templates overriding the built-in ones (see 'piak templates export')")
+	generateCmd.Flags().StringArrayVar(&plugins, "plugin", nil,
+		"Run the external generator piak-gen-<name>, as name or name:parameter (repeatable)")
+	generateCmd.Flags().StringVar(&propertyNaming, "property-naming", "camel",
+		"Naming style for PHP properties mapped from JSON keys: camel or snake")
+	generateCmd.Flags().StringVar(&readWrite, "read-write", config.ReadWriteOptional,
+		"Handling of readOnly/writeOnly properties: optional, or split for separate request and response classes")
+	generateCmd.Flags().BoolVar(&deprecatedAttr, "deprecated-attributes", false,
+		"Add PHP 8.4 #[\\Deprecated] attributes to deprecated client methods and enum cases")
+	generateCmd.Flags().BoolVar(&deprecNotices, "deprecation-notices", false,
+		"Trigger E_USER_DEPRECATED when a deprecated operation is called")
+	generateCmd.Flags().IntVar(&maxDepth, "max-depth", config.DefaultMaxDepth,
+		"Deepest nesting that fromArray accepts for recursive schemas")
+	generateCmd.Flags().StringVar(&int64Type, "int64", config.Int64Int,
+		"PHP type of int64 integers: int, or string to hold them on 32-bit builds")
+	generateCmd.Flags().StringVar(&decimalType, "decimal", config.DecimalFloat,
+		"PHP type of decimals (format decimal or x-php-type: money): float, string or bigdecimal")
+	generateCmd.Flags().StringVar(&paginationFile, "pagination", "",
+		"YAML or JSON file describing the pagination of operations by operation ID, like x-pagination")
+	generateCmd.Flags().BoolVarP(&watchMode, "watch", "w", false, "Regenerate whenever the spec, a file it references or a custom template changes")
+	generateCmd.Flags().DurationVar(&watchDebounce, "watch-debounce", defaultWatchDebounce,
+		"Time to wait for changes to settle before regenerating in watch mode")
+	generateCmd.Flags().BoolVar(&dryRun, "dry-run", false,
+		"List the files generation would create, update or delete, without writing anything")
+	generateCmd.Flags().BoolVar(&checkMode, "check", false,
+		"Fail when the output directory is not up to date, without writing anything")
 }
 
 // runGenerate executes the generate command.
@@ -62,6 +110,13 @@ func runGenerate(_ *cobra.Command, _ []string) error {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
+	if watchMode {
+		if cfg.DryRun || cfg.Check {
+			return errors.New("--watch cannot be combined with --dry-run or --check")
+		}
+		return watchGeneration(cfg)
+	}
+
 	// Execute generation
 	return executeGeneration(cfg)
 }
@@ -83,6 +138,19 @@ func loadConfigFromFlagsAndFile(_ string) (*config.GenerateConfig, error) {
 		Config:         baseConfig,
 		GenerateClient: generateClient,
 		GenerateTests:  generateTests,
+		TemplatesDir:   templatesDir,
+		Plugins:        plugins,
+		PropertyNaming: propertyNaming,
+		ReadWrite:      readWrite,
+		MaxDepth:       maxDepth,
+		Int64:          int64Type,
+		Decimal:        decimalType,
+		Pagination:     paginationFile,
+		DryRun:         dryRun,
+		Check:          checkMode,
+
+		DeprecatedAttributes: deprecatedAttr,
+		DeprecationNotices:   deprecNotices,
 	}
 
 	// Validate the final configuration
@@ -94,27 +162,74 @@ func loadConfigFromFlagsAndFile(_ string) (*config.GenerateConfig, error) {
 	return cfg, nil
 }
 
-// executeGeneration performs the actual code generation.
+// executeGeneration performs the actual code generation. With --dry-run or --check
+// it lists the changes instead of making them, and --check fails when there are any.
 func executeGeneration(cfg *config.GenerateConfig) error {
+	gen, err := runGenerator(cfg)
+	if err != nil || (!cfg.DryRun && !cfg.Check) {
+		return err
+	}
+
+	changes := gen.Changes()
+	for _, change := range changes {
+		fmt.Fprintf(os.Stdout, "%s %s\n", changeSymbols[change.Action], filepath.ToSlash(change.Path))
+	}
+	if cfg.Check && len(changes) > 0 {
+		return fmt.Errorf("%s is out of date, regenerate it", cfg.Output)
+	}
+	return nil
+}
+
+// changeSymbols prefix the files listed by --dry-run and --check.
+var changeSymbols = map[string]string{
+	generator.ChangeCreate: "+",
+	generator.ChangeUpdate: "~",
+	generator.ChangeDelete: "-",
+}
+
+// runGenerator runs a single generation and returns the generator so callers can
+// inspect which specification files were read, even when generation failed.
+func runGenerator(cfg *config.GenerateConfig) (*generator.Generator, error) {
 	genConfig := cfg.ToGeneratorConfig()
 
 	// Create generator instance
 	gen, err := generator.NewGenerator(genConfig)
 	if err != nil {
-		return fmt.Errorf("failed to create generator: %w", err)
+		return nil, fmt.Errorf("failed to create generator: %w", err)
 	}
 
 	// Check if output directory exists and create it if needed
-	if _, statErr := os.Stat(cfg.Output); os.IsNotExist(statErr) {
+	if _, statErr := os.Stat(cfg.Output); os.IsNotExist(statErr) && !genConfig.DryRun {
 		if mkdirErr := os.MkdirAll(cfg.Output, 0755); mkdirErr != nil {
-			return fmt.Errorf("failed to create output directory: %w", mkdirErr)
+			return gen, fmt.Errorf("failed to create output directory: %w", mkdirErr)
 		}
 	}
 
 	// Generate code
 	if genErr := gen.Generate(); genErr != nil {
-		return fmt.Errorf("code generation failed: %w", genErr)
+		return gen, fmt.Errorf("code generation failed: %w", genErr)
 	}
 
-	return nil
+	for _, warning := range gen.Warnings() {
+		fmt.Fprintf(os.Stderr, "⚠️  %s\n", warning)
+	}
+	for _, note := range gen.Notes() {
+		fmt.Fprintf(os.Stderr, "ℹ️  %s\n", note)
+	}
+	if deprecations := gen.Deprecations(); len(deprecations) > 0 {
+		fmt.Fprintf(os.Stderr, "🗑️  %s in the spec:\n", count(len(deprecations), "deprecated element"))
+		for _, deprecation := range deprecations {
+			fmt.Fprintf(os.Stderr, "   - %s\n", deprecation)
+		}
+	}
+
+	return gen, nil
+}
+
+// count returns a number followed by a noun, made plural unless the number is 1.
+func count(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
 }
@@ -196,6 +196,20 @@ func TestGenerateCmd_Initialization(t *testing.T) {
 	assert.NotNil(t, flags.Lookup("namespace"))
 	assert.NotNil(t, flags.Lookup("generate-client"))
 	assert.NotNil(t, flags.Lookup("generate-tests"))
+	assert.NotNil(t, flags.Lookup("templates"))
+	assert.NotNil(t, flags.Lookup("plugin"))
+	assert.NotNil(t, flags.Lookup("property-naming"))
+	assert.NotNil(t, flags.Lookup("read-write"))
+	assert.NotNil(t, flags.Lookup("deprecated-attributes"))
+	assert.NotNil(t, flags.Lookup("deprecation-notices"))
+	assert.NotNil(t, flags.Lookup("max-depth"))
+	assert.NotNil(t, flags.Lookup("int64"))
+	assert.NotNil(t, flags.Lookup("decimal"))
+	assert.NotNil(t, flags.Lookup("pagination"))
+	assert.NotNil(t, flags.Lookup("watch"))
+	assert.NotNil(t, flags.Lookup("watch-debounce"))
+	assert.NotNil(t, flags.Lookup("dry-run"))
+	assert.NotNil(t, flags.Lookup("check"))
 }
 
 func TestRunGenerate_Success(t *testing.T) {
@@ -481,3 +495,9 @@ paths: {}
 	assert.False(t, cfg.GenerateClient) // Custom value
 	assert.True(t, cfg.GenerateTests)   // Custom value
 }
+
+func TestCount(t *testing.T) {
+	assert.Equal(t, "1 deprecated element", count(1, "deprecated element"))
+	assert.Equal(t, "3 deprecated elements", count(3, "deprecated element"))
+	assert.Equal(t, "0 files", count(0, "file"))
+}
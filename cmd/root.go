@@ -40,6 +40,7 @@ func init() {
 
 	// Add commands
 	rootCmd.AddCommand(generateCmd)
+	rootCmd.AddCommand(templatesCmd)
 	rootCmd.AddCommand(versionCmd)
 }
 
@@ -38,6 +38,7 @@ func TestRootCmd_Initialization(t *testing.T) {
 		commandNames[i] = cmd.Use
 	}
 	assert.Contains(t, commandNames, "generate")
+	assert.Contains(t, commandNames, "templates")
 	assert.Contains(t, commandNames, "version")
 }
 
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/floriscornel/piak/internal/templates"
+	"github.com/spf13/cobra"
+)
+
+var exportForce bool
+
+// templatesCmd groups the template related subcommands.
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "Work with the built-in code generation templates",
+	Long: `Work with the built-in code generation templates.
+
+Any template can be overridden by passing a directory to 'piak generate --templates'.
+A file in that directory replaces the built-in template with the same name, and a
+{{define}} block replaces the built-in partial with the same name.`,
+}
+
+// templatesExportCmd writes the built-in templates to a directory.
+var templatesExportCmd = &cobra.Command{
+	Use:   "export <directory>",
+	Short: "Export the built-in templates as a starting point for customization",
+	Long: `Export the built-in templates to a directory, keeping their layout.
+
+Examples:
+  piak templates export ./piak-templates
+  piak generate -i api.yaml -o ./generated -n "MyApp\\Api" --templates ./piak-templates`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTemplatesExport,
+}
+
+func init() {
+	templatesExportCmd.Flags().BoolVarP(&exportForce, "force", "f", false, "Overwrite existing files")
+	templatesCmd.AddCommand(templatesExportCmd)
+}
+
+// runTemplatesExport executes the templates export command.
+func runTemplatesExport(cmd *cobra.Command, args []string) error {
+	written, err := templates.Export(args[0], exportForce)
+	if err != nil {
+		return fmt.Errorf("failed to export templates: %w", err)
+	}
+
+	_, verboseFlag := GetGlobalFlags()
+	if verboseFlag {
+		for _, path := range written {
+			fmt.Fprintln(cmd.OutOrStdout(), path)
+		}
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Exported %d templates to %s\n", len(written), args[0])
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplatesCmd_Initialization(t *testing.T) {
+	assert.Equal(t, "templates", templatesCmd.Use)
+	assert.NotEmpty(t, templatesCmd.Short)
+
+	commands := templatesCmd.Commands()
+	require.Len(t, commands, 1)
+	assert.Equal(t, "export <directory>", commands[0].Use)
+	assert.NotNil(t, templatesExportCmd.Flags().Lookup("force"))
+}
+
+func TestRunTemplatesExport(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	origForce := exportForce
+	defer func() { exportForce = origForce }()
+	exportForce = false
+
+	var out bytes.Buffer
+	templatesExportCmd.SetOut(&out)
+	defer templatesExportCmd.SetOut(nil)
+
+	err := runTemplatesExport(templatesExportCmd, []string{tmpDir})
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "Exported")
+	assert.FileExists(t, filepath.Join(tmpDir, "model.php.tmpl"))
+	assert.FileExists(t, filepath.Join(tmpDir, "partials", "constructor", "signature.tmpl"))
+
+	// A second export refuses to overwrite the customized files
+	err = runTemplatesExport(templatesExportCmd, []string{tmpDir})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "file already exists")
+
+	// Unless forced
+	exportForce = true
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "model.php.tmpl"), []byte("custom"), 0644))
+	err = runTemplatesExport(templatesExportCmd, []string{tmpDir})
+	require.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(tmpDir, "model.php.tmpl"))
+	require.NoError(t, err)
+	assert.NotEqual(t, "custom", string(content))
+}
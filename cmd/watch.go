@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/floriscornel/piak/internal/generator"
+	"github.com/floriscornel/piak/internal/watcher"
+)
+
+const (
+	defaultWatchDebounce = 300 * time.Millisecond
+	watchPollInterval    = 200 * time.Millisecond
+)
+
+// watchSession tracks the files and directories a watched generation depends on.
+type watchSession struct {
+	cfg     *config.GenerateConfig
+	sources []string
+}
+
+// watchGeneration generates once and then regenerates whenever the spec, one of
+// the files it references or a custom template changes, until interrupted.
+func watchGeneration(cfg *config.GenerateConfig) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	input, err := filepath.Abs(cfg.Input)
+	if err != nil {
+		return fmt.Errorf("failed to resolve input path: %w", err)
+	}
+
+	session := &watchSession{cfg: cfg, sources: []string{input}}
+	session.regenerate()
+
+	fmt.Fprintf(os.Stderr, "👀 Watching %s for changes, press Ctrl+C to stop\n",
+		count(len(session.watchedPaths()), "file"))
+
+	w := watcher.New(watchPollInterval, watchDebounce, session.watchedPaths)
+	err = w.Run(ctx, func(changed []string) {
+		for _, path := range changed {
+			fmt.Fprintf(os.Stderr, "🔄 Changed: %s\n", displayPath(path))
+		}
+		session.regenerate()
+	})
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// regenerate runs a generation and reports the outcome, including the files of the
+// previous generation it deleted as they are no longer generated. On failure the
+// previous output is left untouched and the watched set is widened, never narrowed,
+// so a broken $ref can still be fixed without restarting.
+func (s *watchSession) regenerate() {
+	start := time.Now()
+
+	gen, err := runGenerator(s.cfg)
+	if err != nil {
+		if gen != nil {
+			s.sources = mergePaths(s.sources, gen.SourceFiles())
+		}
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return
+	}
+
+	s.sources = gen.SourceFiles()
+	for _, change := range gen.Changes() {
+		if change.Action == generator.ChangeDelete {
+			fmt.Fprintf(os.Stderr, "🧹 Removed %s\n", filepath.ToSlash(change.Path))
+		}
+	}
+	fmt.Fprintf(os.Stderr, "✅ Generated %s in %s\n", s.cfg.Output, time.Since(start).Round(time.Millisecond))
+}
+
+// watchedPaths returns the files and directories that trigger a regeneration.
+func (s *watchSession) watchedPaths() []string {
+	if s.cfg.TemplatesDir == "" {
+		return s.sources
+	}
+	return mergePaths(s.sources, []string{s.cfg.TemplatesDir})
+}
+
+// mergePaths returns the union of two path lists, preserving order.
+func mergePaths(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var merged []string
+	for _, path := range append(append([]string{}, a...), b...) {
+		if !seen[path] {
+			seen[path] = true
+			merged = append(merged, path)
+		}
+	}
+	return merged
+}
+
+// displayPath shortens a path relative to the working directory when possible.
+func displayPath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchSession_RegenerateDeletesStaleFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputFilePath := filepath.Join(tmpDir, "api.yaml")
+	openAPIContent := `openapi: 3.0.3
+info: {title: Pets, version: "1"}
+paths: {}
+components:
+  schemas:
+    Pet: {type: object, properties: {name: {type: string}}}
+    Toy: {type: object, properties: {name: {type: string}}}
+`
+	require.NoError(t, os.WriteFile(inputFilePath, []byte(openAPIContent), 0644))
+
+	outputPath := filepath.Join(tmpDir, "out")
+	session := &watchSession{cfg: &config.GenerateConfig{
+		Config:        &config.Config{Input: inputFilePath, Output: outputPath, Namespace: "App"},
+		GenerateTests: true,
+	}}
+	session.regenerate()
+	require.FileExists(t, filepath.Join(outputPath, "src", "Toy.php"))
+
+	// The schema is removed while watching
+	withoutToy := strings.Replace(openAPIContent, "    Toy: {type: object, properties: {name: {type: string}}}\n", "", 1)
+	require.NoError(t, os.WriteFile(inputFilePath, []byte(withoutToy), 0644))
+	session.regenerate()
+	assert.NoFileExists(t, filepath.Join(outputPath, "src", "Toy.php"))
+	assert.NoFileExists(t, filepath.Join(outputPath, "tests", "ToyTest.php"))
+	assert.FileExists(t, filepath.Join(outputPath, "src", "Pet.php"))
+}
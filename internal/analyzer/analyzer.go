@@ -2,13 +2,27 @@ package analyzer
 
 import (
 	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/floriscornel/piak/internal/naming"
+	"github.com/floriscornel/piak/internal/php"
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/iancoleman/strcase"
 )
 
+// classNameSuffix is appended to schema names that would clash with a reserved or
+// builtin PHP class name.
+const classNameSuffix = "Model"
+
 // Analyzer analyzes OpenAPI specifications and extracts information for code generation.
 type Analyzer struct {
-	spec *openapi3.T
+	spec           *openapi3.T
+	reservedNames  []string
+	splitReadWrite bool
+	warnings       []string
 }
 
 // New creates a new Analyzer instance.
@@ -18,15 +32,43 @@ func New(spec *openapi3.T) *Analyzer {
 	}
 }
 
+// ReserveClassNames keeps schemas from taking the names of classes the generator
+// emits itself, such as the API client. It must be called before AnalyzeSchemas.
+func (a *Analyzer) ReserveClassNames(names ...string) {
+	a.reservedNames = append(a.reservedNames, names...)
+}
+
+// SplitReadWrite makes AnalyzeSchemas assign directional schemas a request and a
+// response class name instead of a single one. It must be called before AnalyzeSchemas.
+func (a *Analyzer) SplitReadWrite() {
+	a.splitReadWrite = true
+}
+
+// Warnings returns the non-fatal diagnostics collected during analysis.
+func (a *Analyzer) Warnings() []string {
+	return a.warnings
+}
+
 // SchemaInfo contains information about a schema for code generation.
+// Name is the schema key in the spec and ClassName the PHP class generated for it.
+// Directional schemas differ between requests and responses, see markDirectional;
+// when split, they get RequestClassName and ResponseClassName instead of ClassName.
+// Cycle lists the schemas, including this one, on the reference cycles through
+// this schema, see markCycles; it is empty for schemas that are not recursive.
 type SchemaInfo struct {
-	Name        string
-	Schema      *openapi3.Schema
-	Required    []string
-	Properties  map[string]*openapi3.SchemaRef
-	IsEnum      bool
-	EnumValues  []interface{}
-	Description string
+	Name              string
+	ClassName         string
+	RequestClassName  string
+	ResponseClassName string
+	Directional       bool
+	Schema            *openapi3.Schema
+	Required          []string
+	Properties        map[string]*openapi3.SchemaRef
+	IsEnum            bool
+	EnumValues        []interface{}
+	Description       string
+	Deprecated        bool
+	Cycle             []string
 }
 
 // AnalyzeSchemas extracts and analyzes all schemas from the OpenAPI specification.
@@ -49,6 +91,7 @@ func (a *Analyzer) AnalyzeSchemas() (map[string]*SchemaInfo, error) {
 			Required:    schema.Required,
 			Properties:  schema.Properties,
 			Description: schema.Description,
+			Deprecated:  schema.Deprecated,
 		}
 
 		// Check if it's an enum
@@ -60,5 +103,193 @@ func (a *Analyzer) AnalyzeSchemas() (map[string]*SchemaInfo, error) {
 		schemas[name] = info
 	}
 
+	references := schemaReferences(schemas)
+	markDirectional(schemas, references)
+	markCycles(schemas, references)
+
+	for name, classNames := range a.classNames(schemas) {
+		if len(classNames) == 2 {
+			schemas[name].RequestClassName, schemas[name].ResponseClassName = classNames[0], classNames[1]
+		} else {
+			schemas[name].ClassName = classNames[0]
+		}
+	}
+
 	return schemas, nil
 }
+
+// classNames assigns every schema a PascalCase class name that is a valid PHP class
+// name and unique ignoring case, since PSR-4 autoloading on case-insensitive
+// filesystems cannot tell Pet and PET apart. Schemas whose key already is the class
+// name are assigned first, the rest in key order, so the result does not depend on
+// map order. Renames other than the case conversion itself are reported as warnings.
+// Split directional schemas get two names, for the request and the response class.
+func (a *Analyzer) classNames(schemas map[string]*SchemaInfo) map[string][]string {
+	keys := make([]string, 0, len(schemas))
+	for name := range schemas {
+		keys = append(keys, name)
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		iExact, jExact := naming.Pascal(keys[i]) == keys[i], naming.Pascal(keys[j]) == keys[j]
+		if iExact != jExact {
+			return iExact
+		}
+		return keys[i] < keys[j]
+	})
+
+	taken := make(map[string]string) // lower-case class name -> what took it
+	for _, name := range a.reservedNames {
+		taken[strings.ToLower(name)] = "a generated class"
+	}
+
+	classNames := make(map[string][]string, len(keys))
+	for _, key := range keys {
+		bases := []string{naming.Pascal(key)}
+		if a.splitReadWrite && schemas[key].Directional {
+			bases = []string{bases[0] + "Request", bases[0] + "Response"}
+		}
+
+		for _, base := range bases {
+			if php.IsReservedClassName(base) {
+				a.warnings = append(a.warnings, fmt.Sprintf(
+					"schema %q is a reserved PHP class name, generating %s instead", key, base+classNameSuffix))
+				base += classNameSuffix
+			}
+
+			className := base
+			for n := 2; taken[strings.ToLower(className)] != ""; n++ {
+				className = base + strconv.Itoa(n)
+			}
+			if className != base {
+				a.warnings = append(a.warnings, fmt.Sprintf(
+					"schema %q would be class %s, which is already used by %s, generating %s instead",
+					key, base, taken[strings.ToLower(base)], className))
+			}
+
+			taken[strings.ToLower(className)] = fmt.Sprintf("schema %q", key)
+			classNames[key] = append(classNames[key], className)
+		}
+	}
+
+	return classNames
+}
+
+// SchemaNameFromRef returns the schema key a reference such as
+// "#/components/schemas/Pet" or "common.yaml#/components/schemas/Pet" points to.
+func SchemaNameFromRef(ref string) string {
+	name := ref[strings.LastIndex(ref, "/")+1:]
+	// Undo JSON pointer escaping
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(name)
+}
+
+// OperationInfo contains information about an operation for code generation.
+// Parameters holds the operation's parameters followed by those of its path item
+// that it does not override. Security holds the security requirements that apply
+// to it, the spec's unless it declares its own. RequestBody describes its request
+// body, if any. ResponseSchema is the schema of the JSON body of its successful
+// response, if any, ResponseContent the media types of that response, and
+// ErrorResponses its documented error responses. Servers are the servers of the
+// operation, or else of its path, overriding those of the spec.
+type OperationInfo struct {
+	OperationID     string
+	Method          string
+	Path            string
+	PathItem        *openapi3.PathItem
+	Operation       *openapi3.Operation
+	Parameters      openapi3.Parameters
+	Security        openapi3.SecurityRequirements
+	RequestBody     *RequestBodyInfo
+	ResponseSchema  *openapi3.SchemaRef
+	ResponseContent []*MediaTypeInfo
+	ErrorResponses  []*ErrorResponseInfo
+	Servers         []*ServerInfo
+}
+
+// AnalyzeOperations extracts all operations from the OpenAPI specification, sorted by
+// path and method. Operations without an operationId get one derived from method and path.
+func (a *Analyzer) AnalyzeOperations() []*OperationInfo {
+	if a.spec.Paths == nil {
+		return nil
+	}
+
+	var operations []*OperationInfo
+
+	for _, path := range a.spec.Paths.InMatchingOrder() {
+		pathItem := a.spec.Paths.Value(path)
+		for method, operation := range pathItem.Operations() {
+			operationID := operation.OperationID
+			if operationID == "" {
+				operationID = deriveOperationID(method, path)
+			}
+
+			operations = append(operations, &OperationInfo{
+				OperationID:     operationID,
+				Method:          method,
+				Path:            path,
+				PathItem:        pathItem,
+				Operation:       operation,
+				Parameters:      a.mergeParameters(pathItem.Parameters, operation.Parameters),
+				Security:        a.operationSecurity(operation),
+				RequestBody:     requestBody(operation),
+				ResponseSchema:  successSchema(operation),
+				ResponseContent: successContent(operation),
+				ErrorResponses:  errorResponses(operation),
+				Servers:         a.operationServers(path, pathItem, operation, operationID),
+			})
+		}
+	}
+
+	sort.Slice(operations, func(i, j int) bool {
+		if operations[i].Path != operations[j].Path {
+			return operations[i].Path < operations[j].Path
+		}
+		return operations[i].Method < operations[j].Method
+	})
+
+	return operations
+}
+
+// mergeParameters returns the operation parameters followed by the path parameters
+// not overridden by one with the same name and location. References to
+// components.parameters are resolved.
+func (a *Analyzer) mergeParameters(pathParams, operationParams openapi3.Parameters) openapi3.Parameters {
+	var merged openapi3.Parameters
+	for _, param := range operationParams {
+		if param = a.resolveParameter(param); param != nil {
+			merged = append(merged, param)
+		}
+	}
+	for _, param := range pathParams {
+		param = a.resolveParameter(param)
+		if param != nil && merged.GetByInAndName(param.Value.In, param.Value.Name) == nil {
+			merged = append(merged, param)
+		}
+	}
+	return merged
+}
+
+// resolveParameter returns a parameter with its value, looking references the
+// loader left unresolved up in components.parameters, or nil when it has none.
+func (a *Analyzer) resolveParameter(param *openapi3.ParameterRef) *openapi3.ParameterRef {
+	if param == nil {
+		return nil
+	}
+	if param.Value != nil {
+		return param
+	}
+	if a.spec.Components == nil || !strings.HasPrefix(param.Ref, "#/components/parameters/") {
+		return nil
+	}
+	component := a.spec.Components.Parameters[SchemaNameFromRef(param.Ref)]
+	if component == nil || component.Value == nil {
+		a.warnings = append(a.warnings, fmt.Sprintf("parameter %s not found, ignoring it", param.Ref))
+		return nil
+	}
+	return &openapi3.ParameterRef{Ref: param.Ref, Value: component.Value}
+}
+
+// deriveOperationID builds an operation ID such as "getPetsPetId" from "GET /pets/{petId}".
+func deriveOperationID(method, path string) string {
+	replacer := strings.NewReplacer("{", "", "}", "", "/", " ", "-", " ", ".", " ")
+	return strcase.ToLowerCamel(strings.ToLower(method) + " " + replacer.Replace(path))
+}
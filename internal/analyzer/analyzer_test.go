@@ -0,0 +1,316 @@
+package analyzer_test
+
+import (
+	"testing"
+
+	"github.com/floriscornel/piak/internal/analyzer"
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func specWithSchemas(names ...string) *openapi3.T {
+	schemas := make(openapi3.Schemas)
+	for _, name := range names {
+		schemas[name] = openapi3.NewObjectSchema().NewRef()
+	}
+	return &openapi3.T{Components: &openapi3.Components{Schemas: schemas}}
+}
+
+func TestAnalyzeSchemas_ClassNames(t *testing.T) {
+	a := analyzer.New(specWithSchemas("pet_status", "Pet.Status", "PetStatus", "PETSTATUS", "Exception", "list", "ApiClient"))
+	a.ReserveClassNames("ApiClient")
+
+	schemas, err := a.AnalyzeSchemas()
+	require.NoError(t, err)
+
+	classNames := make(map[string]string)
+	for name, schema := range schemas {
+		classNames[name] = schema.ClassName
+	}
+	assert.Equal(t, map[string]string{
+		"PetStatus":  "PetStatus",
+		"PETSTATUS":  "Petstatus2",
+		"Pet.Status": "PetStatus3",
+		"pet_status": "PetStatus4",
+		"Exception":  "ExceptionModel",
+		"list":       "ListModel",
+		"ApiClient":  "ApiClient2",
+	}, classNames)
+	assert.Len(t, a.Warnings(), 6)
+}
+
+func TestAnalyzeSchemas_ReadWrite(t *testing.T) {
+	pet := openapi3.NewObjectSchema().
+		WithProperty("id", &openapi3.Schema{Type: &openapi3.Types{"integer"}, ReadOnly: true}).
+		WithProperty("name", openapi3.NewStringSchema())
+	owner := openapi3.NewObjectSchema().WithProperty("pets", openapi3.NewArraySchema())
+	owner.Properties["pets"].Value.Items = &openapi3.SchemaRef{Ref: "#/components/schemas/Pet", Value: pet}
+	spec := &openapi3.T{Components: &openapi3.Components{Schemas: openapi3.Schemas{
+		"Pet":   pet.NewRef(),
+		"Owner": owner.NewRef(),
+		"Tag":   openapi3.NewObjectSchema().WithProperty("label", openapi3.NewStringSchema()).NewRef(),
+	}}}
+
+	schemas, err := analyzer.New(spec).AnalyzeSchemas()
+	require.NoError(t, err)
+	assert.True(t, schemas["Pet"].Directional)
+	assert.True(t, schemas["Owner"].Directional)
+	assert.False(t, schemas["Tag"].Directional)
+	assert.Equal(t, "Pet", schemas["Pet"].ClassName)
+
+	a := analyzer.New(spec)
+	a.SplitReadWrite()
+	schemas, err = a.AnalyzeSchemas()
+	require.NoError(t, err)
+	assert.Empty(t, schemas["Pet"].ClassName)
+	assert.Equal(t, "PetRequest", schemas["Pet"].RequestClassName)
+	assert.Equal(t, "PetResponse", schemas["Pet"].ResponseClassName)
+	assert.Equal(t, "OwnerRequest", schemas["Owner"].RequestClassName)
+	assert.Equal(t, "Tag", schemas["Tag"].ClassName)
+}
+
+func TestAnalyzeSchemas_Cycles(t *testing.T) {
+	ref := func(name string) *openapi3.SchemaRef {
+		return &openapi3.SchemaRef{Ref: "#/components/schemas/" + name, Value: openapi3.NewObjectSchema()}
+	}
+	category := openapi3.NewObjectSchema().WithProperty("children", openapi3.NewArraySchema())
+	category.Properties["children"].Value.Items = ref("Category")
+	a := openapi3.NewObjectSchema()
+	a.Properties = openapi3.Schemas{"b": ref("B")}
+	b := openapi3.NewObjectSchema().WithProperty("byKey", openapi3.NewObjectSchema())
+	b.Properties["byKey"].Value.AdditionalProperties = openapi3.AdditionalProperties{Schema: ref("A")}
+	c := openapi3.NewObjectSchema()
+	c.Properties = openapi3.Schemas{"a": ref("A")}
+	spec := &openapi3.T{Components: &openapi3.Components{Schemas: openapi3.Schemas{
+		"Category": category.NewRef(),
+		"A":        a.NewRef(),
+		"B":        b.NewRef(),
+		"C":        c.NewRef(),
+	}}}
+
+	schemas, err := analyzer.New(spec).AnalyzeSchemas()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Category"}, schemas["Category"].Cycle)
+	assert.Equal(t, []string{"A", "B"}, schemas["A"].Cycle)
+	assert.Equal(t, []string{"A", "B"}, schemas["B"].Cycle)
+	assert.Empty(t, schemas["C"].Cycle)
+}
+
+func TestAnalyzeOperations_Parameters(t *testing.T) {
+	pathItem := &openapi3.PathItem{
+		Parameters: openapi3.Parameters{
+			{Value: openapi3.NewPathParameter("petId").WithDescription("shared")},
+			{Value: openapi3.NewQueryParameter("verbose")},
+		},
+		Get: &openapi3.Operation{
+			OperationID: "getPet",
+			Parameters:  openapi3.Parameters{{Value: openapi3.NewPathParameter("petId").WithDescription("own")}},
+		},
+	}
+	spec := &openapi3.T{Paths: openapi3.NewPaths(openapi3.WithPath("/pets/{petId}", pathItem))}
+
+	operations := analyzer.New(spec).AnalyzeOperations()
+	require.Len(t, operations, 1)
+	require.Len(t, operations[0].Parameters, 2)
+	assert.Equal(t, "own", operations[0].Parameters[0].Value.Description)
+	assert.Equal(t, "verbose", operations[0].Parameters[1].Value.Name)
+}
+
+func TestAnalyzeOperations_ComponentParameters(t *testing.T) {
+	spec := &openapi3.T{
+		Components: &openapi3.Components{Parameters: openapi3.ParametersMap{
+			"Limit": {Value: openapi3.NewQueryParameter("limit")},
+		}},
+		Paths: openapi3.NewPaths(openapi3.WithPath("/pets", &openapi3.PathItem{
+			Get: &openapi3.Operation{OperationID: "listPets", Parameters: openapi3.Parameters{
+				{Ref: "#/components/parameters/Limit"},
+				{Ref: "#/components/parameters/Missing"},
+			}},
+		})),
+	}
+
+	a := analyzer.New(spec)
+	operations := a.AnalyzeOperations()
+	require.Len(t, operations, 1)
+	require.Len(t, operations[0].Parameters, 1)
+	assert.Equal(t, "limit", operations[0].Parameters[0].Value.Name)
+	assert.Equal(t, []string{"parameter #/components/parameters/Missing not found, ignoring it"}, a.Warnings())
+}
+
+func TestAnalyzeOperations_RequestBody(t *testing.T) {
+	multipart := openapi3.NewMediaType().WithSchema(openapi3.NewObjectSchema())
+	multipart.Encoding = map[string]*openapi3.Encoding{
+		"photo": {ContentType: "image/png"},
+		"name":  {},
+	}
+	body := openapi3.NewRequestBody().WithRequired(true).WithContent(openapi3.Content{
+		"multipart/form-data":               multipart,
+		"Application/JSON; charset=utf-8":   openapi3.NewMediaType(),
+		"application/x-www-form-urlencoded": openapi3.NewMediaType(),
+	})
+	spec := &openapi3.T{Paths: openapi3.NewPaths(openapi3.WithPath("/pets", &openapi3.PathItem{
+		Post: &openapi3.Operation{OperationID: "createPet", RequestBody: &openapi3.RequestBodyRef{Value: body}},
+		Get:  &openapi3.Operation{OperationID: "listPets"},
+	}))}
+
+	operations := analyzer.New(spec).AnalyzeOperations()
+	require.Len(t, operations, 2)
+	assert.Nil(t, operations[0].RequestBody)
+
+	info := operations[1].RequestBody
+	require.NotNil(t, info)
+	assert.True(t, info.Required)
+	require.Len(t, info.Content, 3)
+	assert.Equal(t, "application/json", info.Content[0].MediaType)
+	assert.Equal(t, "application/x-www-form-urlencoded", info.Content[1].MediaType)
+	assert.Equal(t, "multipart/form-data", info.Content[2].MediaType)
+	assert.Equal(t, map[string]string{"photo": "image/png"}, info.Content[2].Encoding)
+}
+
+func TestAnalyzeOperations_ErrorResponses(t *testing.T) {
+	errorRef := &openapi3.SchemaRef{Ref: "#/components/schemas/Error", Value: openapi3.NewObjectSchema()}
+	responses := openapi3.NewResponses(
+		openapi3.WithStatus(200, &openapi3.ResponseRef{Value: openapi3.NewResponse().WithDescription("ok")}),
+		openapi3.WithStatus(404, &openapi3.ResponseRef{Value: openapi3.NewResponse().
+			WithDescription("not found").
+			WithContent(openapi3.Content{
+				"application/json":         openapi3.NewMediaType().WithSchemaRef(errorRef),
+				"application/problem+json": openapi3.NewMediaType().WithSchema(openapi3.NewObjectSchema()),
+			})}),
+		openapi3.WithName("5xx", openapi3.NewResponse().WithJSONSchemaRef(errorRef)),
+		openapi3.WithName("default", openapi3.NewResponse()),
+	)
+	spec := &openapi3.T{Paths: openapi3.NewPaths(openapi3.WithPath("/pets", &openapi3.PathItem{
+		Get: &openapi3.Operation{OperationID: "listPets", Responses: responses},
+	}))}
+
+	operations := analyzer.New(spec).AnalyzeOperations()
+	require.Len(t, operations, 1)
+	assert.Equal(t, []*analyzer.ErrorResponseInfo{
+		{Status: "404", Description: "not found", ProblemJSON: true},
+		{Status: "5XX", SchemaName: "Error"},
+		{Status: "default"},
+	}, operations[0].ErrorResponses)
+}
+
+func TestAnalyzeOperations_SuccessResponse(t *testing.T) {
+	created := openapi3.NewArraySchema()
+	responses := openapi3.NewResponses(
+		openapi3.WithName("2XX", openapi3.NewResponse().WithJSONSchema(openapi3.NewObjectSchema())),
+		openapi3.WithStatus(202, &openapi3.ResponseRef{Value: openapi3.NewResponse().WithDescription("accepted")}),
+		openapi3.WithStatus(201, &openapi3.ResponseRef{Value: openapi3.NewResponse().WithJSONSchema(created)}),
+	)
+	spec := &openapi3.T{Paths: openapi3.NewPaths(
+		openapi3.WithPath("/pets", &openapi3.PathItem{
+			Post: &openapi3.Operation{OperationID: "createPets", Responses: responses},
+		}),
+		openapi3.WithPath("/health", &openapi3.PathItem{
+			Get: &openapi3.Operation{OperationID: "health", Responses: openapi3.NewResponses()},
+		}),
+	)}
+
+	operations := analyzer.New(spec).AnalyzeOperations()
+	require.Len(t, operations, 2)
+	assert.Nil(t, operations[0].ResponseSchema)
+	assert.Empty(t, operations[0].ResponseContent)
+	require.NotNil(t, operations[1].ResponseSchema)
+	assert.Same(t, created, operations[1].ResponseSchema.Value)
+	require.Len(t, operations[1].ResponseContent, 1)
+	assert.Equal(t, "application/json", operations[1].ResponseContent[0].MediaType)
+}
+
+func TestAnalyzeSecuritySchemes(t *testing.T) {
+	oauth2 := &openapi3.SecurityScheme{Type: "oauth2", Flows: &openapi3.OAuthFlows{
+		ClientCredentials: &openapi3.OAuthFlow{TokenURL: "/token", Scopes: map[string]string{"write": "", "read": ""}},
+	}}
+	spec := &openapi3.T{
+		Components: &openapi3.Components{SecuritySchemes: openapi3.SecuritySchemes{
+			"key":    {Value: openapi3.NewSecurityScheme().WithType("apiKey").WithIn("query").WithName("api_key")},
+			"basic":  {Value: openapi3.NewSecurityScheme().WithType("http").WithScheme("Basic")},
+			"digest": {Value: openapi3.NewSecurityScheme().WithType("http").WithScheme("digest")},
+			"oauth":  {Value: oauth2},
+			"oidc":   {Value: openapi3.NewSecurityScheme().WithType("openIdConnect")},
+		}},
+		Security: openapi3.SecurityRequirements{{"key": {}}},
+		Paths: openapi3.NewPaths(
+			openapi3.WithPath("/pets", &openapi3.PathItem{Get: &openapi3.Operation{OperationID: "listPets"}}),
+			openapi3.WithPath("/health", &openapi3.PathItem{Get: &openapi3.Operation{
+				OperationID: "health",
+				Security:    &openapi3.SecurityRequirements{},
+			}}),
+		),
+	}
+
+	a := analyzer.New(spec)
+	schemes := a.AnalyzeSecuritySchemes()
+	require.Len(t, schemes, 4)
+	assert.Equal(t, &analyzer.SecuritySchemeInfo{Name: "basic", Kind: config.SecurityBasic}, schemes[0])
+	assert.Equal(t, &analyzer.SecuritySchemeInfo{
+		Name: "key", Kind: config.SecurityAPIKey, In: "query", ParamName: "api_key",
+	}, schemes[1])
+	assert.Equal(t, &analyzer.SecuritySchemeInfo{
+		Name: "oauth", Kind: config.SecurityOAuth2, TokenURL: "/token", Scopes: []string{"read", "write"},
+	}, schemes[2])
+	assert.Equal(t, &analyzer.SecuritySchemeInfo{Name: "oidc", Kind: config.SecurityBearer}, schemes[3])
+	require.Len(t, a.Warnings(), 1)
+	assert.Contains(t, a.Warnings()[0], `"http digest" is not supported`)
+
+	// Operations inherit the spec's requirements unless they declare their own
+	operations := a.AnalyzeOperations()
+	require.Len(t, operations, 2)
+	assert.Empty(t, operations[0].Security)
+	assert.Equal(t, spec.Security, operations[1].Security)
+}
+
+func TestAnalyzeServers(t *testing.T) {
+	region := &openapi3.ServerVariable{Default: "eu", Enum: []string{"eu", "us"}}
+	spec := &openapi3.T{
+		Servers: openapi3.Servers{
+			{URL: "https://{region}.api.example.com/{region}", Description: "Production server",
+				Variables: map[string]*openapi3.ServerVariable{"region": region}},
+			{URL: "/v1", Description: "Relative"},
+			{URL: "https://{host}.example.com", Description: "Undeclared"},
+			{URL: "http://localhost:8080", Description: "Local server used for development"},
+		},
+		Paths: openapi3.NewPaths(openapi3.WithPath("/files", &openapi3.PathItem{
+			Servers: openapi3.Servers{{URL: "https://uploads.example.com", Description: "Uploads"}},
+			Get:     &openapi3.Operation{OperationID: "listFiles"},
+			Put: &openapi3.Operation{OperationID: "putFile", Servers: &openapi3.Servers{
+				{URL: "https://sandbox.example.com", Description: "The sandbox environment"},
+				{URL: "https://a.example.com", Description: "server3"},
+				{URL: "https://b.example.com"},
+				{URL: "https://c.example.com", Description: "Sandbox"},
+			}},
+		})),
+	}
+
+	a := analyzer.New(spec)
+	servers := a.AnalyzeServers()
+	require.Len(t, servers, 2)
+	assert.Equal(t, "production", servers[0].Name)
+	require.Len(t, servers[0].Variables, 1)
+	assert.Equal(t, &analyzer.ServerVariableInfo{Name: "region", Default: "eu", Enum: []string{"eu", "us"}},
+		servers[0].Variables[0])
+	assert.Equal(t, "server4", servers[1].Name)
+	assert.Len(t, a.Warnings(), 2)
+
+	operations := a.AnalyzeOperations()
+	require.Len(t, operations, 2)
+	require.Len(t, operations[0].Servers, 1)
+	assert.Equal(t, "uploads", operations[0].Servers[0].Name)
+	// Names taken by earlier servers fall back to the position, then the next free one
+	require.Len(t, operations[1].Servers, 4)
+	names := make([]string, 0, len(operations[1].Servers))
+	for _, server := range operations[1].Servers {
+		names = append(names, server.Name)
+	}
+	assert.Equal(t, []string{"sandbox", "server3", "server4", "server5"}, names)
+}
+
+func TestSchemaNameFromRef(t *testing.T) {
+	assert.Equal(t, "Pet", analyzer.SchemaNameFromRef("#/components/schemas/Pet"))
+	assert.Equal(t, "Pet", analyzer.SchemaNameFromRef("common.yaml#/components/schemas/Pet"))
+	assert.Equal(t, "a/b~c", analyzer.SchemaNameFromRef("#/components/schemas/a~1b~0c"))
+}
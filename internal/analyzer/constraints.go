@@ -0,0 +1,34 @@
+package analyzer
+
+import (
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// PropertyConstraints extracts the validation keywords of a property schema. It
+// returns nil when the schema has none.
+func PropertyConstraints(schema *openapi3.Schema) *config.Constraints {
+	constraints := &config.Constraints{
+		MaxLength:        schema.MaxLength,
+		Pattern:          schema.Pattern,
+		Minimum:          schema.Min,
+		Maximum:          schema.Max,
+		ExclusiveMinimum: schema.ExclusiveMin && schema.Min != nil,
+		ExclusiveMaximum: schema.ExclusiveMax && schema.Max != nil,
+		MultipleOf:       schema.MultipleOf,
+		MaxItems:         schema.MaxItems,
+		UniqueItems:      schema.UniqueItems,
+	}
+	// Zero is the unset value of the lower bounds
+	if minLength := schema.MinLength; minLength > 0 {
+		constraints.MinLength = &minLength
+	}
+	if minItems := schema.MinItems; minItems > 0 {
+		constraints.MinItems = &minItems
+	}
+
+	if *constraints == (config.Constraints{}) {
+		return nil
+	}
+	return constraints
+}
@@ -0,0 +1,36 @@
+package analyzer
+
+import "sort"
+
+// markDirectional marks the schemas whose request and response shapes differ: those
+// with readOnly or writeOnly properties, and those referencing such a schema, since
+// their classes must use the matching variant.
+func markDirectional(schemas map[string]*SchemaInfo, references map[string][]string) {
+	names := make([]string, 0, len(schemas))
+	for name, info := range schemas {
+		names = append(names, name)
+		for _, propRef := range info.Properties {
+			if propRef.Value != nil && (propRef.Value.ReadOnly || propRef.Value.WriteOnly) {
+				info.Directional = true
+			}
+		}
+	}
+	sort.Strings(names)
+
+	// Propagate until nothing changes, which also handles reference cycles
+	for changed := true; changed; {
+		changed = false
+		for _, name := range names {
+			if schemas[name].Directional {
+				continue
+			}
+			for _, referenced := range references[name] {
+				if target, ok := schemas[referenced]; ok && target.Directional {
+					schemas[name].Directional = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package analyzer
+
+import (
+	"sort"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// RefTarget returns the schema a property schema stands for: the reference of an
+// allOf wrapping a single one, which is how OpenAPI 3.0 adds a default, nullable or
+// description to a reference, and the schema itself otherwise.
+func RefTarget(schemaRef *openapi3.SchemaRef) *openapi3.SchemaRef {
+	if schemaRef == nil || schemaRef.Ref != "" || schemaRef.Value == nil {
+		return schemaRef
+	}
+	schema := schemaRef.Value
+	if len(schema.AllOf) == 1 && schema.AllOf[0] != nil && schema.AllOf[0].Ref != "" &&
+		len(schema.Type.Slice()) == 0 && len(schema.Properties) == 0 {
+		return schema.AllOf[0]
+	}
+	return schemaRef
+}
+
+// schemaReferences returns the schemas each schema refers to through its properties,
+// directly, through a RefTarget wrapper or as array items or additionalProperties at
+// any depth. These are the references that property types, and so the generated
+// classes, follow.
+func schemaReferences(schemas map[string]*SchemaInfo) map[string][]string {
+	references := make(map[string][]string, len(schemas))
+	for name, info := range schemas {
+		seen := make(map[string]bool)
+		var walk func(schemaRef *openapi3.SchemaRef)
+		walk = func(schemaRef *openapi3.SchemaRef) {
+			schemaRef = RefTarget(schemaRef)
+			switch {
+			case schemaRef == nil || schemaRef.Value == nil:
+			case schemaRef.Ref != "":
+				if referenced := SchemaNameFromRef(schemaRef.Ref); !seen[referenced] {
+					seen[referenced] = true
+					references[name] = append(references[name], referenced)
+				}
+			default:
+				walk(schemaRef.Value.Items)
+				walk(schemaRef.Value.AdditionalProperties.Schema)
+			}
+		}
+		for _, propRef := range info.Properties {
+			walk(propRef)
+		}
+		sort.Strings(references[name])
+	}
+	return references
+}
+
+// markCycles sets Cycle on every schema that refers back to itself, directly or
+// through other schemas, to the sorted keys of the schemas on its cycles: the
+// strongly connected component of the reference graph containing it.
+func markCycles(schemas map[string]*SchemaInfo, references map[string][]string) {
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Tarjan's algorithm
+	index := make(map[string]int, len(schemas))
+	lowLink := make(map[string]int, len(schemas))
+	onStack := make(map[string]bool, len(schemas))
+	var stack []string
+	var visit func(name string)
+	visit = func(name string) {
+		index[name] = len(index)
+		lowLink[name] = index[name]
+		stack = append(stack, name)
+		onStack[name] = true
+
+		selfReference := false
+		for _, referenced := range references[name] {
+			if _, ok := schemas[referenced]; !ok {
+				continue
+			}
+			selfReference = selfReference || referenced == name
+			if _, visited := index[referenced]; !visited {
+				visit(referenced)
+				lowLink[name] = min(lowLink[name], lowLink[referenced])
+			} else if onStack[referenced] {
+				lowLink[name] = min(lowLink[name], index[referenced])
+			}
+		}
+		if lowLink[name] != index[name] {
+			return
+		}
+
+		var component []string
+		for {
+			member := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[member] = false
+			component = append(component, member)
+			if member == name {
+				break
+			}
+		}
+		if len(component) == 1 && !selfReference {
+			return
+		}
+		sort.Strings(component)
+		for _, member := range component {
+			schemas[member].Cycle = component
+		}
+	}
+
+	for _, name := range names {
+		if _, visited := index[name]; !visited {
+			visit(name)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+	"mime"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// RequestBodyInfo describes the request body of an operation: whether it is
+// required and the media types it may be sent as, sorted by media type.
+type RequestBodyInfo struct {
+	Required bool
+	Content  []*MediaTypeInfo
+}
+
+// MediaTypeInfo is a media type a body may be sent as, without parameters and in
+// lower case. Encoding maps the properties of multipart and form bodies to the
+// content type their encoding object sets, if any.
+type MediaTypeInfo struct {
+	MediaType string
+	Schema    *openapi3.SchemaRef
+	Encoding  map[string]string
+}
+
+// requestBody returns the request body of an operation, or nil when it has none.
+func requestBody(operation *openapi3.Operation) *RequestBodyInfo {
+	if operation.RequestBody == nil || operation.RequestBody.Value == nil {
+		return nil
+	}
+	body := operation.RequestBody.Value
+
+	return &RequestBodyInfo{Required: body.Required, Content: mediaTypes(body.Content)}
+}
+
+// mediaTypes returns the media types of a content map, sorted by media type.
+func mediaTypes(content openapi3.Content) []*MediaTypeInfo {
+	var infos []*MediaTypeInfo
+	for key, media := range content {
+		if media == nil {
+			continue
+		}
+		mediaType, _, err := mime.ParseMediaType(key)
+		if err != nil {
+			mediaType = strings.ToLower(key)
+		}
+		info := &MediaTypeInfo{MediaType: mediaType, Schema: media.Schema}
+		for property, encoding := range media.Encoding {
+			if encoding != nil && encoding.ContentType != "" {
+				if info.Encoding == nil {
+					info.Encoding = make(map[string]string)
+				}
+				info.Encoding[property] = encoding.ContentType
+			}
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].MediaType < infos[j].MediaType
+	})
+	return infos
+}
@@ -0,0 +1,163 @@
+package analyzer
+
+import (
+	"mime"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// ErrorResponseInfo describes a documented error response of an operation. Status
+// is a status code of 400 or above, a range such as "4XX" or "default". SchemaName
+// is the key of the component schema of its JSON body, if the body references one.
+// ProblemJSON is set when the body is an RFC 7807 application/problem+json document.
+type ErrorResponseInfo struct {
+	Status      string
+	Description string
+	SchemaName  string
+	ProblemJSON bool
+}
+
+// errorResponses returns the error responses of an operation, with status codes
+// first, then ranges, then the default response.
+func errorResponses(operation *openapi3.Operation) []*ErrorResponseInfo {
+	if operation.Responses == nil {
+		return nil
+	}
+
+	var responses []*ErrorResponseInfo
+	for status, ref := range operation.Responses.Map() {
+		status = strings.ToUpper(status)
+		if status == "DEFAULT" {
+			status = "default"
+		}
+		if !isErrorStatus(status) || ref == nil || ref.Value == nil {
+			continue
+		}
+
+		info := &ErrorResponseInfo{Status: status}
+		if ref.Value.Description != nil {
+			info.Description = *ref.Value.Description
+		}
+		if mediaType, media := jsonContent(ref.Value.Content); media != nil {
+			info.ProblemJSON = mediaType == "application/problem+json"
+			if media.Schema != nil && media.Schema.Ref != "" {
+				info.SchemaName = SchemaNameFromRef(media.Schema.Ref)
+			}
+		}
+		responses = append(responses, info)
+	}
+
+	sort.Slice(responses, func(i, j int) bool {
+		return statusOrder(responses[i].Status) < statusOrder(responses[j].Status)
+	})
+	return responses
+}
+
+// successSchema returns the schema of the JSON body of an operation's successful
+// response: the 2xx response with the lowest status code having one, the 2XX range
+// coming last.
+func successSchema(operation *openapi3.Operation) *openapi3.SchemaRef {
+	if operation.Responses == nil {
+		return nil
+	}
+
+	statuses := make([]string, 0, operation.Responses.Len())
+	for status := range operation.Responses.Map() {
+		if strings.HasPrefix(status, "2") {
+			statuses = append(statuses, strings.ToUpper(status))
+		}
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		response := operation.Responses.Value(status)
+		if response == nil {
+			response = operation.Responses.Value(strings.ToLower(status))
+		}
+		if response == nil || response.Value == nil {
+			continue
+		}
+		if _, media := jsonContent(response.Value.Content); media != nil && media.Schema != nil {
+			return media.Schema
+		}
+	}
+	return nil
+}
+
+// successContent returns the media types of an operation's successful response: the
+// 2xx response with the lowest status code declaring content, the 2XX range coming
+// last.
+func successContent(operation *openapi3.Operation) []*MediaTypeInfo {
+	if operation.Responses == nil {
+		return nil
+	}
+
+	statuses := make([]string, 0, operation.Responses.Len())
+	for status := range operation.Responses.Map() {
+		if strings.HasPrefix(status, "2") {
+			statuses = append(statuses, status)
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return strings.ToUpper(statuses[i]) < strings.ToUpper(statuses[j])
+	})
+	for _, status := range statuses {
+		response := operation.Responses.Value(status)
+		if response != nil && response.Value != nil && len(response.Value.Content) > 0 {
+			return mediaTypes(response.Value.Content)
+		}
+	}
+	return nil
+}
+
+// isErrorStatus reports whether a response status of the spec is an error: a code
+// of 400 or above, a 4XX or 5XX range or the default response.
+func isErrorStatus(status string) bool {
+	return status == "default" || len(status) == 3 && (status[0] == '4' || status[0] == '5')
+}
+
+// statusOrder returns the sort key of a response status.
+func statusOrder(status string) string {
+	switch {
+	case status == "default":
+		return "2"
+	case strings.HasSuffix(status, "XX"):
+		return "1" + status
+	default:
+		return "0" + status
+	}
+}
+
+// jsonContent returns the JSON media type of a response body and its content,
+// preferring application/problem+json, then application/json, then other +json types.
+func jsonContent(content openapi3.Content) (string, *openapi3.MediaType) {
+	keys := make([]string, 0, len(content))
+	for key := range content {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var best string
+	var bestMedia *openapi3.MediaType
+	bestRank := 0
+	for _, key := range keys {
+		base, _, err := mime.ParseMediaType(key)
+		if err != nil {
+			continue
+		}
+		rank := 0
+		switch {
+		case base == "application/problem+json":
+			rank = 3
+		case base == "application/json":
+			rank = 2
+		case strings.HasSuffix(base, "+json"):
+			rank = 1
+		}
+		if rank > bestRank {
+			best, bestMedia, bestRank = base, content[key], rank
+		}
+	}
+	return best, bestMedia
+}
@@ -0,0 +1,93 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// SecuritySchemeInfo describes a security scheme of components.securitySchemes, see
+// config.SecuritySchemeModel for its fields.
+type SecuritySchemeInfo struct {
+	Name        string
+	Kind        string
+	In          string
+	ParamName   string
+	TokenURL    string
+	Scopes      []string
+	Description string
+}
+
+// AnalyzeSecuritySchemes returns the security schemes the client supports, sorted by
+// name. OAuth2 schemes without a client credentials flow and OpenID Connect schemes
+// take an access token as bearer token. Other schemes are reported as warnings and
+// left out.
+func (a *Analyzer) AnalyzeSecuritySchemes() []*SecuritySchemeInfo {
+	if a.spec.Components == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(a.spec.Components.SecuritySchemes))
+	for name := range a.spec.Components.SecuritySchemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var schemes []*SecuritySchemeInfo
+	for _, name := range names {
+		ref := a.spec.Components.SecuritySchemes[name]
+		if ref == nil || ref.Value == nil {
+			continue
+		}
+		scheme := ref.Value
+		info := &SecuritySchemeInfo{Name: name, Description: scheme.Description}
+
+		switch {
+		case scheme.Type == "apiKey" && (scheme.In == "header" || scheme.In == "query" || scheme.In == "cookie"):
+			info.Kind = config.SecurityAPIKey
+			info.In = scheme.In
+			info.ParamName = scheme.Name
+		case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic"):
+			info.Kind = config.SecurityBasic
+		case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "bearer"):
+			info.Kind = config.SecurityBearer
+		case scheme.Type == "oauth2" && scheme.Flows != nil && scheme.Flows.ClientCredentials != nil:
+			info.Kind = config.SecurityOAuth2
+			info.TokenURL = scheme.Flows.ClientCredentials.TokenURL
+			for scope := range scheme.Flows.ClientCredentials.Scopes {
+				info.Scopes = append(info.Scopes, scope)
+			}
+			sort.Strings(info.Scopes)
+		case scheme.Type == "oauth2" || scheme.Type == "openIdConnect":
+			info.Kind = config.SecurityBearer
+		default:
+			a.warnings = append(a.warnings, fmt.Sprintf(
+				"security scheme %q of type %q is not supported, configure it on the HTTP client instead",
+				name, describeScheme(scheme)))
+			continue
+		}
+		schemes = append(schemes, info)
+	}
+	return schemes
+}
+
+// describeScheme names the type of a security scheme for warnings, e.g. http digest.
+func describeScheme(scheme *openapi3.SecurityScheme) string {
+	if scheme.Type == "http" && scheme.Scheme != "" {
+		return scheme.Type + " " + scheme.Scheme
+	}
+	return scheme.Type
+}
+
+// operationSecurity returns the security requirements of an operation: its own, or
+// the spec's when it declares none. An empty requirement means authentication is
+// optional; no requirements at all mean none is needed.
+func (a *Analyzer) operationSecurity(operation *openapi3.Operation) openapi3.SecurityRequirements {
+	if operation.Security != nil {
+		return *operation.Security
+	}
+	return a.spec.Security
+}
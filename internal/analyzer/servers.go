@@ -0,0 +1,128 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/floriscornel/piak/internal/naming"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// ServerInfo describes a server of the spec, see config.ServerModel for its fields.
+// Variables are in the order they appear in the URL.
+type ServerInfo struct {
+	Name        string
+	URL         string
+	Description string
+	Variables   []*ServerVariableInfo
+}
+
+// ServerVariableInfo describes a variable of a server URL.
+type ServerVariableInfo struct {
+	Name        string
+	Default     string
+	Enum        []string
+	Description string
+}
+
+// serverVariable matches a variable such as {region} in a server URL.
+var serverVariable = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// serverNameNoise are the words of server descriptions that do not tell servers apart.
+var serverNameNoise = map[string]bool{
+	"the": true, "api": true, "server": true, "servers": true, "environment": true, "url": true,
+}
+
+// AnalyzeServers returns the servers of the spec. Servers with a relative URL, which
+// is relative to where the spec is served, and servers using undeclared variables
+// are reported as warnings and left out.
+func (a *Analyzer) AnalyzeServers() []*ServerInfo {
+	return a.servers(a.spec.Servers, "spec")
+}
+
+// operationServers returns the servers of an operation: its own, or else those of its
+// path, nil when neither declares any and the servers of the spec apply.
+func (a *Analyzer) operationServers(
+	path string, pathItem *openapi3.PathItem, operation *openapi3.Operation, operationID string,
+) []*ServerInfo {
+	if operation.Servers != nil && len(*operation.Servers) > 0 {
+		return a.servers(*operation.Servers, fmt.Sprintf("operation %q", operationID))
+	}
+	if len(pathItem.Servers) > 0 {
+		return a.servers(pathItem.Servers, fmt.Sprintf("path %q", path))
+	}
+	return nil
+}
+
+// servers converts a list of servers declared by owner, the spec, a path or an
+// operation alike, naming each after its description, or else its position, such as
+// server2, unique within the list.
+func (a *Analyzer) servers(servers openapi3.Servers, owner string) []*ServerInfo {
+	var infos []*ServerInfo
+	taken := make(map[string]bool)
+	for i, server := range servers {
+		if server == nil {
+			continue
+		}
+		if !strings.HasPrefix(server.URL, "http://") && !strings.HasPrefix(server.URL, "https://") &&
+			!strings.HasPrefix(server.URL, "{") {
+			a.warnings = append(a.warnings, fmt.Sprintf(
+				"server %q of the %s has a relative URL, pass the base URL to the client instead", server.URL, owner))
+			continue
+		}
+
+		info := &ServerInfo{URL: server.URL, Description: server.Description}
+		valid := true
+		seen := make(map[string]bool)
+		for _, match := range serverVariable.FindAllStringSubmatch(server.URL, -1) {
+			name := match[1]
+			variable := server.Variables[name]
+			if variable == nil {
+				a.warnings = append(a.warnings, fmt.Sprintf(
+					"server %q of the %s uses undeclared variable %q, ignoring the server", server.URL, owner, name))
+				valid = false
+				break
+			}
+			if !seen[name] {
+				seen[name] = true
+				info.Variables = append(info.Variables, &ServerVariableInfo{
+					Name:        name,
+					Default:     variable.Default,
+					Enum:        variable.Enum,
+					Description: variable.Description,
+				})
+			}
+		}
+		if !valid {
+			continue
+		}
+
+		// Names are unique ignoring case, as PHP method names are
+		name := serverName(server.Description)
+		for n := i + 1; name == "" || taken[strings.ToLower(name)]; n++ {
+			name = "server" + strconv.Itoa(n)
+		}
+		taken[strings.ToLower(name)] = true
+		info.Name = name
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// serverName derives the name of a server from its description, such as production
+// from "Production server", or returns an empty string when the description is
+// missing or too long to make a name of.
+func serverName(description string) string {
+	var words []string
+	for _, word := range naming.Words(description) {
+		if !serverNameNoise[strings.ToLower(word)] {
+			words = append(words, word)
+		}
+	}
+	if len(words) == 0 || len(words) > 3 {
+		return ""
+	}
+	return naming.Camel(strings.Join(words, " "))
+}
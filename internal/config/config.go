@@ -16,8 +16,20 @@ type Config struct {
 // GenerateConfig holds generation-specific configuration.
 type GenerateConfig struct {
 	*Config
-	GenerateClient bool `mapstructure:"generate_client" flag:"generate-client" usage:"Generate HTTP client code" default:"true"`
-	GenerateTests  bool `mapstructure:"generate_tests"  flag:"generate-tests"  usage:"Generate test files"       default:"false"`
+	GenerateClient       bool     `mapstructure:"generate_client" flag:"generate-client" usage:"Generate HTTP client code" default:"true"`
+	GenerateTests        bool     `mapstructure:"generate_tests"  flag:"generate-tests"  usage:"Generate test files"       default:"false"`
+	TemplatesDir         string   `mapstructure:"templates"       flag:"templates"       usage:"Template override directory"`
+	Plugins              []string `mapstructure:"plugins"         flag:"plugin"          usage:"External generator plugins"`
+	PropertyNaming       string   `mapstructure:"property_naming" flag:"property-naming" usage:"PHP property naming style" default:"camel"`
+	ReadWrite            string   `mapstructure:"read_write"      flag:"read-write"      usage:"readOnly/writeOnly handling" default:"optional"`
+	DeprecatedAttributes bool     `mapstructure:"deprecated_attributes" flag:"deprecated-attributes" usage:"Add Deprecated attributes"`
+	DeprecationNotices   bool     `mapstructure:"deprecation_notices"   flag:"deprecation-notices"   usage:"Trigger E_USER_DEPRECATED"`
+	MaxDepth             int      `mapstructure:"max_depth"       flag:"max-depth"       usage:"Nesting limit of recursive models" default:"64"`
+	Int64                string   `mapstructure:"int64"           flag:"int64"           usage:"PHP type of int64 integers" default:"int"`
+	Decimal              string   `mapstructure:"decimal"         flag:"decimal"         usage:"PHP type of decimals" default:"float"`
+	Pagination           string   `mapstructure:"pagination"      flag:"pagination"      usage:"Pagination of operations by ID"`
+	DryRun               bool     `mapstructure:"dry_run"         flag:"dry-run"         usage:"List changes without writing"`
+	Check                bool     `mapstructure:"check"           flag:"check"           usage:"Fail when the output is stale"`
 }
 
 // Loader handles configuration validation.
@@ -106,5 +118,17 @@ func (cfg *GenerateConfig) ToGeneratorConfig() *GeneratorConfig {
 		Namespace:      cfg.Namespace,
 		GenerateTests:  cfg.GenerateTests,
 		GenerateClient: cfg.GenerateClient,
+		TemplatesDir:   cfg.TemplatesDir,
+		Plugins:        cfg.Plugins,
+		PropertyNaming: cfg.PropertyNaming,
+		ReadWrite:      cfg.ReadWrite,
+		MaxDepth:       cfg.MaxDepth,
+		Int64:          cfg.Int64,
+		Decimal:        cfg.Decimal,
+		PaginationFile: cfg.Pagination,
+		DryRun:         cfg.DryRun || cfg.Check,
+
+		DeprecatedAttributes: cfg.DeprecatedAttributes,
+		DeprecationNotices:   cfg.DeprecationNotices,
 	}
 }
@@ -3,52 +3,357 @@ package config
 import "github.com/getkin/kin-openapi/openapi3"
 
 // PHPType represents a PHP type with additional metadata.
+// Arrays are lists, or maps with string keys when IsMap is set, of Items. DocComment
+// is the PHPDoc type of the value, such as list<Pet>, without null, and DataType
+// that of its JSON data as accepted by fromArray, such as list<PetData>. For a
+// class or enum generated from a schema, Model is its model. Numeric is set on
+// integers and decimals held as numeric strings or BigDecimal objects, see Int64
+// and Decimal in GeneratorConfig.
 type PHPType struct {
-	Name       string
-	IsNullable bool
-	IsArray    bool
-	DocComment string
+	Name       string       `json:"name"`
+	IsNullable bool         `json:"is_nullable"`
+	IsArray    bool         `json:"is_array"`
+	IsMap      bool         `json:"is_map,omitempty"`
+	IsEnum     bool         `json:"is_enum"`
+	DocComment string       `json:"doc_comment"`
+	DataType   string       `json:"data_type,omitempty"`
+	Items      *PHPType     `json:"items,omitempty"`
+	Numeric    string       `json:"numeric,omitempty"`
+	Model      *SchemaModel `json:"-"`
 }
 
+// Numeric representations of PHPType: integers and decimals as numeric strings, and
+// decimals as Brick\Math\BigDecimal objects.
+const (
+	NumericIntString     = "int-string"
+	NumericDecimalString = "decimal-string"
+	NumericBigDecimal    = "bigdecimal"
+)
+
 // Property represents a schema property.
+// Name is the wire name used in JSON; PHPName is the PHP property name it maps to.
+// Default is the decoded schema default, nil when there is none; for a property
+// whose type is a generated enum, DefaultCase names the matching case. ReadOnly
+// properties are only sent by the server, WriteOnly ones only by the client.
 type Property struct {
-	Name        string
-	PHPType     PHPType
-	OpenAPIType *openapi3.Schema
-	Required    bool
-	Description string
+	Name        string           `json:"name"`
+	PHPName     string           `json:"php_name"`
+	PHPType     PHPType          `json:"php_type"`
+	OpenAPIType *openapi3.Schema `json:"openapi_type"`
+	Required    bool             `json:"required"`
+	Description string           `json:"description"`
+	EnumValues  []interface{}    `json:"enum_values,omitempty"`
+	Default     interface{}      `json:"default,omitempty"`
+	DefaultCase string           `json:"default_case,omitempty"`
+	Constraints *Constraints     `json:"constraints,omitempty"`
+	ReadOnly    bool             `json:"read_only,omitempty"`
+	WriteOnly   bool             `json:"write_only,omitempty"`
+	Deprecated  bool             `json:"deprecated,omitempty"`
+}
+
+// Constraints are the validation keywords of a property's schema, nil when unset.
+// Allowed values are in Property.EnumValues.
+type Constraints struct {
+	MinLength        *uint64  `json:"min_length,omitempty"`
+	MaxLength        *uint64  `json:"max_length,omitempty"`
+	Pattern          string   `json:"pattern,omitempty"`
+	Minimum          *float64 `json:"minimum,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMinimum bool     `json:"exclusive_minimum,omitempty"`
+	ExclusiveMaximum bool     `json:"exclusive_maximum,omitempty"`
+	MultipleOf       *float64 `json:"multiple_of,omitempty"`
+	MinItems         *uint64  `json:"min_items,omitempty"`
+	MaxItems         *uint64  `json:"max_items,omitempty"`
+	UniqueItems      bool     `json:"unique_items,omitempty"`
 }
 
+// Directions of the request and response variants of a schema.
+const (
+	DirectionRequest  = "request"
+	DirectionResponse = "response"
+)
+
 // SchemaModel represents an analyzed schema ready for code generation.
+// Direction is set on the request and response variants generated for a schema
+// with readOnly or writeOnly properties when ReadWrite is ReadWriteSplit. DataType
+// is the PHPStan type alias of the array data of a model class, e.g. PetData.
+// Recursive is set on models that refer back to themselves, directly or through
+// other models; their fromArray limits the nesting depth to MaxDepth.
 type SchemaModel struct {
-	Name         string
-	PHPType      string
-	OriginalName string
-	Properties   []*Property
-	IsEnum       bool
-	EnumValues   []interface{}
-	Description  string
+	Name         string        `json:"name"`
+	PHPType      string        `json:"php_type"`
+	OriginalName string        `json:"original_name"`
+	Properties   []*Property   `json:"properties"`
+	IsEnum       bool          `json:"is_enum"`
+	EnumValues   []interface{} `json:"enum_values"`
+	EnumCases    []*EnumCase   `json:"enum_cases,omitempty"`
+	Description  string        `json:"description"`
+	Direction    string        `json:"direction,omitempty"`
+	Deprecated   bool          `json:"deprecated,omitempty"`
+	DataType     string        `json:"data_type,omitempty"`
+	Recursive    bool          `json:"recursive,omitempty"`
+
+	ExternalDocs *openapi3.ExternalDocs `json:"external_docs,omitempty"`
+}
+
+// EnumCase is a case of a generated backed enum. Value is a string or an int.
+// Deprecated values are listed in the x-enum-deprecated extension of the schema.
+type EnumCase struct {
+	Name       string      `json:"name"`
+	Value      interface{} `json:"value"`
+	Deprecated bool        `json:"deprecated,omitempty"`
+}
+
+// OperationModel represents an analyzed API operation.
+// MethodName is the name of the client method calling it, unique ignoring case.
+// Security lists the alternative sets of security schemes the operation accepts,
+// each mapping scheme names to the OAuth2 scopes needed; an empty set makes
+// authentication optional. Without any, the operation needs no authentication.
+// Retryable overrides whether the client may retry the operation, which it otherwise
+// decides by the method, when the spec marks it with x-retryable. Pagination is set
+// for operations whose results come in pages. RequestBody is set for operations
+// taking a body, and Response for those declaring the content of their successful
+// response. Servers are the servers the operation is sent to instead of the client's,
+// when it declares its own.
+type OperationModel struct {
+	OperationID string            `json:"operation_id"`
+	MethodName  string            `json:"method_name"`
+	Method      string            `json:"method"`
+	Path        string            `json:"path"`
+	Summary     string            `json:"summary"`
+	Description string            `json:"description"`
+	Tags        []string          `json:"tags"`
+	Deprecated  bool              `json:"deprecated,omitempty"`
+	Parameters  []*ParameterModel `json:"parameters,omitempty"`
+
+	Security       []map[string][]string `json:"security,omitempty"`
+	ErrorResponses []*ErrorResponseModel `json:"error_responses,omitempty"`
+	Retryable      *bool                 `json:"retryable,omitempty"`
+	Pagination     *PaginationModel      `json:"pagination,omitempty"`
+	RequestBody    *RequestBodyModel     `json:"request_body,omitempty"`
+	Response       *ResponseModel        `json:"response,omitempty"`
+	Servers        []*ServerModel        `json:"servers,omitempty"`
+}
+
+// Response kinds: a JSON document decoded into an array, a binary body returned as a
+// stream, newline-delimited JSON values decoded one at a time, or server-sent events.
+const (
+	ResponseJSON   = "json"
+	ResponseBinary = "binary"
+	ResponseNDJSON = "ndjson"
+	ResponseEvents = "events"
+)
+
+// ResponseModel describes the successful response of an operation. MediaTypes are
+// the ones it declares, which binary, NDJSON and event stream requests accept.
+// ItemClass and ItemDataType are the model NDJSON values and the data of events are
+// hydrated into, if any. JSONEvents is set when the data of events is JSON rather
+// than text.
+type ResponseModel struct {
+	Kind         string   `json:"kind"`
+	MediaTypes   []string `json:"media_types"`
+	ItemClass    string   `json:"item_class,omitempty"`
+	ItemDataType string   `json:"item_data_type,omitempty"`
+	JSONEvents   bool     `json:"json_events,omitempty"`
+}
+
+// Media types of request bodies the client can send. JSON also stands for the
+// +json media types, such as application/merge-patch+json, and octet-stream for
+// */*; octet-stream bodies are sent as given rather than encoded from the data.
+const (
+	MediaTypeJSON        = "application/json"
+	MediaTypeMultipart   = "multipart/form-data"
+	MediaTypeForm        = "application/x-www-form-urlencoded"
+	MediaTypeOctetStream = "application/octet-stream"
+)
+
+// RequestBodyModel describes the request body of an operation. MediaType is the
+// one the client sends, chosen among MediaTypes: JSON first, then multipart, then
+// form-urlencoded, then octet-stream. For multipart bodies, Files are the properties
+// holding files and PartContentTypes the content types the spec sets for parts.
+type RequestBodyModel struct {
+	Required         bool              `json:"required"`
+	MediaType        string            `json:"media_type"`
+	MediaTypes       []string          `json:"media_types"`
+	Files            []string          `json:"files,omitempty"`
+	PartContentTypes map[string]string `json:"part_content_types,omitempty"`
+}
+
+// Pagination styles: a cursor from the body passed back in a parameter, an offset
+// and limit, or the next page's URL in a Link header.
+const (
+	PaginationCursor = "cursor"
+	PaginationOffset = "offset"
+	PaginationLink   = "link"
+)
+
+// PaginationModel describes how an operation pages its results. Items is the
+// dot-separated path of the item list in the response body, which only link
+// pagination may leave empty, when the body is the list. Cursor pagination passes
+// the value at NextCursor back in CursorParam; offset pagination advances
+// OffsetParam by the items received, stopping at a short page, judged by
+// LimitParam, or at the total at Total. IteratorName is the client
+// method iterating over all items, hydrated into ItemClass, whose array data is
+// ItemDataType, when the items are models.
+type PaginationModel struct {
+	Style        string `json:"style"`
+	Items        string `json:"items,omitempty"`
+	CursorParam  string `json:"cursor_param,omitempty"`
+	NextCursor   string `json:"next_cursor,omitempty"`
+	OffsetParam  string `json:"offset_param,omitempty"`
+	LimitParam   string `json:"limit_param,omitempty"`
+	Total        string `json:"total,omitempty"`
+	IteratorName string `json:"iterator_name"`
+	ItemClass    string `json:"item_class,omitempty"`
+	ItemDataType string `json:"item_data_type,omitempty"`
+}
+
+// ErrorResponseModel is a documented error response of an operation. Status is a
+// status code, a range such as "4XX" or "default", and Exception the class of the
+// exception the client throws for it. ClassName is the model class its body is
+// hydrated into and DataType the PHPStan alias of that class's array data; both are
+// empty when the body does not reference a model schema. ProblemJSON is set for RFC
+// 7807 application/problem+json bodies.
+type ErrorResponseModel struct {
+	Status      string `json:"status"`
+	Description string `json:"description,omitempty"`
+	Exception   string `json:"exception"`
+	ClassName   string `json:"class_name,omitempty"`
+	DataType    string `json:"data_type,omitempty"`
+	ProblemJSON bool   `json:"problem_json,omitempty"`
+}
+
+// Kinds of security schemes the generated client authenticates with.
+const (
+	SecurityAPIKey = "apiKey" // an API key in a header, query parameter or cookie
+	SecurityBasic  = "basic"  // HTTP basic authentication
+	SecurityBearer = "bearer" // a bearer token, such as an OAuth2 access token obtained elsewhere
+	SecurityOAuth2 = "oauth2" // OAuth2 client credentials, the client fetching the token itself
+)
+
+// SecuritySchemeModel is a security scheme of the spec that the client supports.
+// For API keys, In is "header", "query" or "cookie" and ParamName the name of the
+// header, query parameter or cookie. For OAuth2 client credentials, TokenURL is the
+// token endpoint and Scopes the scopes of the flow. SetterName is the client method
+// setting the credentials of the scheme, unique ignoring case among client methods.
+type SecuritySchemeModel struct {
+	Name        string   `json:"name"`
+	Kind        string   `json:"kind"`
+	In          string   `json:"in,omitempty"`
+	ParamName   string   `json:"param_name,omitempty"`
+	TokenURL    string   `json:"token_url,omitempty"`
+	Scopes      []string `json:"scopes,omitempty"`
+	Description string   `json:"description,omitempty"`
+	SetterName  string   `json:"setter_name"`
+}
+
+// ServerModel is a server of the spec, or of an operation. Name is derived from its
+// description, unique among the servers declared together. URL may contain
+// variables in braces. ConstructorName is the named constructor of the client
+// creating it for the server, unique ignoring case among client methods, and is
+// empty for the servers of operations.
+type ServerModel struct {
+	Name            string                 `json:"name"`
+	URL             string                 `json:"url"`
+	Description     string                 `json:"description,omitempty"`
+	Variables       []*ServerVariableModel `json:"variables,omitempty"`
+	ConstructorName string                 `json:"constructor_name,omitempty"`
+}
+
+// ServerVariableModel is a variable of a server URL, which takes one of the values of
+// Enum when it has any. ParamName is the argument of the named constructor taking it.
+type ServerVariableModel struct {
+	Name        string   `json:"name"`
+	Default     string   `json:"default"`
+	Enum        []string `json:"enum,omitempty"`
+	Description string   `json:"description,omitempty"`
+	ParamName   string   `json:"param_name"`
+}
+
+// ParameterModel represents an operation parameter, including those shared by all
+// operations of a path. In is "path", "query", "header" or "cookie". Style and
+// Explode tell how its value is serialized, defaulting as OpenAPI does by location:
+// "simple" without explode in paths and headers, "form" with explode in queries and
+// cookies.
+type ParameterModel struct {
+	Name        string           `json:"name"`
+	In          string           `json:"in"`
+	Description string           `json:"description"`
+	Required    bool             `json:"required"`
+	Deprecated  bool             `json:"deprecated,omitempty"`
+	Style       string           `json:"style"`
+	Explode     bool             `json:"explode"`
+	OpenAPIType *openapi3.Schema `json:"openapi_type,omitempty"`
 }
 
 // InternalModel represents the complete analyzed OpenAPI specification.
+// SecuritySchemes are sorted by name.
 type InternalModel struct {
-	Info    *InfoModel
-	Schemas map[string]*SchemaModel
-	Config  *GeneratorConfig
+	Info            *InfoModel              `json:"info"`
+	Schemas         map[string]*SchemaModel `json:"schemas"`
+	Operations      []*OperationModel       `json:"operations"`
+	SecuritySchemes []*SecuritySchemeModel  `json:"security_schemes,omitempty"`
+	Servers         []*ServerModel          `json:"servers,omitempty"`
+	Config          *GeneratorConfig        `json:"config"`
 }
 
 // InfoModel represents OpenAPI info section.
 type InfoModel struct {
-	Title       string
-	Version     string
-	Description string
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
 }
 
+// Ways of generating schemas with readOnly or writeOnly properties: a single class
+// in which those properties are optional, or separate request and response classes.
+const (
+	ReadWriteOptional = "optional"
+	ReadWriteSplit    = "split"
+)
+
+// Representations of integers with format int64: PHP ints, which overflow on 32-bit
+// builds, or numeric strings.
+const (
+	Int64Int    = "int"
+	Int64String = "string"
+)
+
+// Representations of decimals, numbers or strings with format decimal or the
+// extension x-php-type: money: floats, which lose precision, numeric strings or
+// Brick\Math\BigDecimal objects.
+const (
+	DecimalFloat      = "float"
+	DecimalString     = "string"
+	DecimalBigDecimal = "bigdecimal"
+)
+
+// DefaultMaxDepth is the nesting depth recursive models accept by default.
+const DefaultMaxDepth = 64
+
 // GeneratorConfig holds the essential settings for code generation.
+// DeprecatedAttributes adds #[\Deprecated] where PHP allows it, DeprecationNotices an
+// E_USER_DEPRECATED notice to the client methods of deprecated operations. MaxDepth
+// is the deepest nesting recursive models accept, DefaultMaxDepth when zero. Int64
+// and Decimal choose how int64 integers and decimals are held, Int64Int and
+// DecimalFloat when empty. PaginationFile is a YAML or JSON file describing the
+// pagination of operations by operation ID, as their x-pagination extension does.
+// DryRun renders the files and compares them with the output directory without
+// writing anything.
 type GeneratorConfig struct {
-	InputFile      string `yaml:"input_file"`
-	Namespace      string `yaml:"namespace"       validate:"required"`
-	OutputDir      string `yaml:"output_dir"      validate:"required"`
-	GenerateTests  bool   `yaml:"generate_tests"`
-	GenerateClient bool   `yaml:"generate_client"`
+	InputFile            string   `yaml:"input_file"            json:"input_file"`
+	Namespace            string   `yaml:"namespace"             json:"namespace"             validate:"required"`
+	OutputDir            string   `yaml:"output_dir"            json:"output_dir"            validate:"required"`
+	GenerateTests        bool     `yaml:"generate_tests"        json:"generate_tests"`
+	GenerateClient       bool     `yaml:"generate_client"       json:"generate_client"`
+	TemplatesDir         string   `yaml:"templates_dir"         json:"templates_dir"`
+	Plugins              []string `yaml:"plugins"               json:"plugins"`
+	PropertyNaming       string   `yaml:"property_naming"       json:"property_naming"`
+	ReadWrite            string   `yaml:"read_write"            json:"read_write"`
+	DeprecatedAttributes bool     `yaml:"deprecated_attributes" json:"deprecated_attributes"`
+	DeprecationNotices   bool     `yaml:"deprecation_notices"   json:"deprecation_notices"`
+	MaxDepth             int      `yaml:"max_depth"             json:"max_depth"`
+	Int64                string   `yaml:"int64"                 json:"int64"`
+	Decimal              string   `yaml:"decimal"               json:"decimal"`
+	PaginationFile       string   `yaml:"pagination_file"       json:"pagination_file"`
+	DryRun               bool     `yaml:"dry_run"               json:"dry_run"`
 }
@@ -0,0 +1,77 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/floriscornel/piak/internal/analyzer"
+	"github.com/floriscornel/piak/internal/config"
+)
+
+// deprecatedEnumExtension lists the deprecated values of an enum schema, which
+// OpenAPI cannot mark individually.
+const deprecatedEnumExtension = "x-enum-deprecated"
+
+// markDeprecatedCases marks the cases whose value is listed in the extension.
+func (c *modelConverter) markDeprecatedCases(cases []*config.EnumCase, extension interface{}) {
+	values, ok := extension.([]interface{})
+	if !ok {
+		return
+	}
+	for _, enumCase := range cases {
+		for _, value := range values {
+			if fmt.Sprint(normalizeNumber(value, c.integerText)) == fmt.Sprint(enumCase.Value) {
+				enumCase.Deprecated = true
+			}
+		}
+	}
+}
+
+// deprecations describes the deprecated elements of the spec in spec terms, schemas
+// in key order followed by operations, for the generation summary.
+func deprecations(
+	names []string,
+	schemas map[string]*analyzer.SchemaInfo,
+	enums map[string][]*config.EnumCase,
+	operations []*config.OperationModel,
+) []string {
+	var found []string
+
+	for _, name := range names {
+		schema := schemas[name]
+		if schema.Deprecated {
+			found = append(found, fmt.Sprintf("schema %s", name))
+		}
+
+		properties := make([]string, 0, len(schema.Properties))
+		for property, propRef := range schema.Properties {
+			if propRef.Value != nil && propRef.Value.Deprecated {
+				properties = append(properties, property)
+			}
+		}
+		sort.Strings(properties)
+		for _, property := range properties {
+			found = append(found, fmt.Sprintf("property %s.%s", name, property))
+		}
+
+		for _, enumCase := range enums[name] {
+			if enumCase.Deprecated {
+				found = append(found, fmt.Sprintf("enum value %s %v", name, enumCase.Value))
+			}
+		}
+	}
+
+	for _, op := range operations {
+		if op.Deprecated {
+			found = append(found, fmt.Sprintf("operation %s (%s %s)", op.OperationID, strings.ToUpper(op.Method), op.Path))
+		}
+		for _, param := range op.Parameters {
+			if param.Deprecated {
+				found = append(found, fmt.Sprintf("%s parameter %s of operation %s", param.In, param.Name, op.OperationID))
+			}
+		}
+	}
+
+	return found
+}
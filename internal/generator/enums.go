@@ -0,0 +1,128 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/floriscornel/piak/internal/analyzer"
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/floriscornel/piak/internal/naming"
+	"github.com/floriscornel/piak/internal/php"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// enumCases converts the values of an enum schema to the cases of a backed enum.
+// PHP enums are backed by either strings or ints, so other values and mixed
+// enums are converted to strings. null marks a nullable enum and is not a case.
+func (c *modelConverter) enumCases(name string, values []interface{}) []*config.EnumCase {
+	var normalized []interface{}
+	hasString, hasInt, converted := false, false, false
+	for _, value := range values {
+		switch v := normalizeNumber(value, c.integerText).(type) {
+		case nil:
+			continue
+		case string:
+			hasString = true
+			normalized = append(normalized, v)
+		case int:
+			hasInt = true
+			normalized = append(normalized, v)
+		default:
+			converted = true
+			normalized = append(normalized, fmt.Sprint(v))
+		}
+	}
+
+	if converted || (hasString && hasInt) {
+		c.warnings = append(c.warnings, fmt.Sprintf(
+			"schema %q: enum values are not all strings or all integers, generating a string-backed enum", name))
+		for i, value := range normalized {
+			normalized[i] = fmt.Sprint(value)
+		}
+	}
+
+	labels := make([]string, len(normalized))
+	for i, value := range normalized {
+		labels[i] = fmt.Sprint(value)
+	}
+
+	cases := make([]*config.EnumCase, len(normalized))
+	for i, caseName := range naming.EnumCaseNames(labels) {
+		cases[i] = &config.EnumCase{Name: caseName, Value: normalized[i]}
+	}
+	return cases
+}
+
+// applyDefault checks a property's default against its type. Defaults of generated
+// enums are resolved to a case, and defaults that cannot be expressed as a PHP
+// constant expression are dropped with a warning.
+func (c *modelConverter) applyDefault(owner string, prop *config.Property, propRef *openapi3.SchemaRef) {
+	if prop.Default == nil {
+		return
+	}
+
+	switch {
+	case prop.PHPType.IsEnum:
+		for _, enumCase := range c.enums[analyzer.SchemaNameFromRef(analyzer.RefTarget(propRef).Ref)] {
+			if fmt.Sprint(enumCase.Value) == fmt.Sprint(prop.Default) {
+				prop.DefaultCase = enumCase.Name
+				return
+			}
+		}
+		c.dropDefault(owner, prop, "it is not one of the enum values")
+	case prop.PHPType.Numeric == config.NumericBigDecimal:
+		c.dropDefault(owner, prop, "PHP has no constant expression for a BigDecimal, pass it to the constructor")
+	case !php.IsBuiltinType(prop.PHPType.Name):
+		c.dropDefault(owner, prop, "defaults for object types are not supported")
+	case propRef.Value.Type.Includes("integer") && !isInteger(normalizeNumber(propRef.Value.Default, c.integerText)):
+		c.dropDefault(owner, prop, fmt.Sprintf("%v cannot be represented exactly", propRef.Value.Default))
+	default:
+		if _, err := php.Literal(prop.Default); err != nil {
+			c.dropDefault(owner, prop, err.Error())
+		}
+	}
+}
+
+// dropDefault removes a property's default and reports why.
+func (c *modelConverter) dropDefault(owner string, prop *config.Property, reason string) {
+	c.warnings = append(c.warnings, fmt.Sprintf(
+		"schema %q: ignoring default of property %q: %s", owner, prop.Name, reason))
+	prop.Default = nil
+}
+
+// isInteger reports whether a normalized number is an integer.
+func isInteger(value interface{}) bool {
+	switch value.(type) {
+	case int, json.Number:
+		return true
+	}
+	return false
+}
+
+// caseValues returns the backing values of enum cases.
+func caseValues(cases []*config.EnumCase) []interface{} {
+	values := make([]interface{}, len(cases))
+	for i, enumCase := range cases {
+		values[i] = enumCase.Value
+	}
+	return values
+}
+
+// normalizeNumber turns whole numbers, which decoded JSON represents as floats, into
+// ints. Floats only hold integers exactly below 2^53, so larger ones become the
+// json.Number of the literal integerText finds for them, and stay floats when it
+// finds none.
+func normalizeNumber(value interface{}, integerText func(float64) (string, bool)) interface{} {
+	f, ok := value.(float64)
+	if !ok || f != math.Trunc(f) {
+		return value
+	}
+	if math.Abs(f) < 1<<53 {
+		return int(f)
+	}
+	if text, found := integerText(f); found {
+		return json.Number(text)
+	}
+	return value
+}
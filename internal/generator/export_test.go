@@ -0,0 +1,4 @@
+package generator
+
+// NormalizeNumber exposes normalizeNumber to the tests of package generator_test.
+var NormalizeNumber = normalizeNumber
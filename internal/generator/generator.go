@@ -2,11 +2,14 @@ package generator
 
 import (
 	"fmt"
-	"strings"
+	"path/filepath"
+	"sort"
 
 	"github.com/floriscornel/piak/internal/analyzer"
 	"github.com/floriscornel/piak/internal/config"
+	"github.com/floriscornel/piak/internal/naming"
 	"github.com/floriscornel/piak/internal/parser"
+	"github.com/floriscornel/piak/internal/templates"
 	"github.com/getkin/kin-openapi/openapi3"
 )
 
@@ -14,9 +17,48 @@ const arrayType = "array"
 
 // Generator coordinates the entire generation process.
 type Generator struct {
-	config *config.GeneratorConfig
-	parser *parser.OpenAPIParser
-	phpGen *PHPGenerator
+	config         *config.GeneratorConfig
+	parser         *parser.OpenAPIParser
+	phpGen         *PHPGenerator
+	propertyNaming naming.Style
+	splitReadWrite bool
+	warnings       []string
+	deprecations   []string
+}
+
+// supportClassNames are the classes generated next to the models in src/.
+var supportClassNames = append([]string{
+	"ApiClient", templates.CurlClientClass, templates.CurlNetworkExceptionClass, templates.RetryPolicyClass,
+	templates.UndefinedClass, templates.ValidationExceptionClass,
+	templates.APIExceptionClass, templates.ClientErrorExceptionClass, templates.ServerErrorExceptionClass,
+	templates.ProblemDetailsClass, templates.ServerSentEventClass,
+}, statusExceptionClasses()...)
+
+// statusExceptionClasses returns the classes of the exceptions of specific status codes.
+func statusExceptionClasses() []string {
+	classes := make([]string, 0, len(templates.StatusExceptions))
+	for _, exception := range templates.StatusExceptions {
+		classes = append(classes, exception.ClassName)
+	}
+	return classes
+}
+
+// modelConverter converts analyzed schemas to the internal model.
+type modelConverter struct {
+	propertyNaming  naming.Style
+	classNames      map[string]string             // schema key -> PHP class name
+	enums           map[string][]*config.EnumCase // schema key -> cases, for enum schemas
+	variants        map[string]map[string]string  // schema key -> direction -> class, for split schemas
+	direction       string                        // direction of the variant being converted, if any
+	dataTypes       map[string]string             // model class -> PHPStan alias of its array data
+	cycles          map[string][]string           // schema key -> schemas on its reference cycles
+	current         string                        // key of the schema being converted
+	securitySchemes map[string]bool               // names of the security schemes the client supports
+	pagination      map[string]*paginationSpec    // operation ID -> pagination from the pagination file
+	int64           string                        // representation of int64 integers
+	decimal         string                        // representation of decimals
+	integerText     func(float64) (string, bool)  // literal of a large integer of the spec, see normalizeNumber
+	warnings        []string
 }
 
 // NewGenerator creates a new Generator instance.
@@ -26,10 +68,41 @@ func NewGenerator(cfg *config.GeneratorConfig) (*Generator, error) {
 		return nil, fmt.Errorf("failed to create PHP generator: %w", err)
 	}
 
+	propertyNaming, err := naming.ParseStyle(cfg.PropertyNaming)
+	if err != nil {
+		return nil, fmt.Errorf("invalid property naming: %w", err)
+	}
+
+	if cfg.MaxDepth < 0 {
+		return nil, fmt.Errorf("invalid max depth %d, expected a positive number", cfg.MaxDepth)
+	}
+
+	switch cfg.ReadWrite {
+	case "", config.ReadWriteOptional, config.ReadWriteSplit:
+	default:
+		return nil, fmt.Errorf("invalid read-write handling %q, expected %q or %q",
+			cfg.ReadWrite, config.ReadWriteOptional, config.ReadWriteSplit)
+	}
+
+	switch cfg.Int64 {
+	case "", config.Int64Int, config.Int64String:
+	default:
+		return nil, fmt.Errorf("invalid int64 type %q, expected %q or %q", cfg.Int64, config.Int64Int, config.Int64String)
+	}
+
+	switch cfg.Decimal {
+	case "", config.DecimalFloat, config.DecimalString, config.DecimalBigDecimal:
+	default:
+		return nil, fmt.Errorf("invalid decimal type %q, expected %q, %q or %q",
+			cfg.Decimal, config.DecimalFloat, config.DecimalString, config.DecimalBigDecimal)
+	}
+
 	return &Generator{
-		config: cfg,
-		parser: parser.New(true, true), // validateSpec=true, resolveRefs=true
-		phpGen: phpGen,
+		config:         cfg,
+		parser:         parser.New(true, true), // validateSpec=true, resolveRefs=true
+		phpGen:         phpGen,
+		propertyNaming: propertyNaming,
+		splitReadWrite: cfg.ReadWrite == config.ReadWriteSplit,
 	}, nil
 }
 
@@ -41,27 +114,104 @@ func (g *Generator) Generate() error {
 		return fmt.Errorf("failed to parse OpenAPI specification: %w", err)
 	}
 
+	var pagination map[string]*paginationSpec
+	if g.config.PaginationFile != "" {
+		pagination, err = loadPaginationFile(g.config.PaginationFile)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Analyze the specification
-	analyzer := analyzer.New(spec)
-	schemas, err := analyzer.AnalyzeSchemas()
+	specAnalyzer := analyzer.New(spec)
+	specAnalyzer.ReserveClassNames(supportClassNames...)
+	if g.splitReadWrite {
+		specAnalyzer.SplitReadWrite()
+	}
+	schemas, err := specAnalyzer.AnalyzeSchemas()
 	if err != nil {
 		return fmt.Errorf("failed to analyze OpenAPI specification: %w", err)
 	}
+	securitySchemes := specAnalyzer.AnalyzeSecuritySchemes()
+	servers := specAnalyzer.AnalyzeServers()
+	operationInfos := specAnalyzer.AnalyzeOperations()
+	g.warnings = specAnalyzer.Warnings()
+
+	// Schemas are converted in key order so warnings come out in a stable order
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	converter := &modelConverter{
+		propertyNaming: g.propertyNaming,
+		classNames:     make(map[string]string, len(schemas)),
+		enums:          make(map[string][]*config.EnumCase),
+		variants:       make(map[string]map[string]string),
+		cycles:         make(map[string][]string),
+		int64:          g.config.Int64,
+		decimal:        g.config.Decimal,
+		integerText:    g.parser.IntegerText,
+
+		securitySchemes: make(map[string]bool, len(securitySchemes)),
+		pagination:      pagination,
+	}
+	for _, scheme := range securitySchemes {
+		converter.securitySchemes[scheme.Name] = true
+	}
+	for _, name := range names {
+		converter.classNames[name] = schemas[name].ClassName
+		converter.cycles[name] = schemas[name].Cycle
+		if schemas[name].IsEnum {
+			converter.enums[name] = converter.enumCases(name, schemas[name].EnumValues)
+			converter.markDeprecatedCases(converter.enums[name], schemas[name].Schema.Extensions[deprecatedEnumExtension])
+		}
+		if schemas[name].RequestClassName != "" {
+			converter.variants[name] = map[string]string{
+				config.DirectionRequest:  schemas[name].RequestClassName,
+				config.DirectionResponse: schemas[name].ResponseClassName,
+			}
+		}
+	}
+
+	// Every model class describes its array data with a type alias, which the
+	// properties referencing it use, so all aliases are assigned up front
+	var modelClasses, otherClasses []string
+	for _, name := range names {
+		switch {
+		case schemas[name].IsEnum:
+			otherClasses = append(otherClasses, schemas[name].ClassName)
+		case converter.variants[name] != nil:
+			modelClasses = append(modelClasses, schemas[name].RequestClassName, schemas[name].ResponseClassName)
+		default:
+			modelClasses = append(modelClasses, schemas[name].ClassName)
+		}
+	}
+	converter.dataTypes = dataTypeAliases(modelClasses, append(otherClasses, supportClassNames...))
 
-	// Convert to new types format
+	// Convert to new types format, keyed by schema key. The variants of a split
+	// schema are keyed by schema key and direction, such as "Pet#request".
 	schemaModels := make(map[string]*config.SchemaModel)
-	for name, schema := range schemas {
-		schemaModel := &config.SchemaModel{
-			Name:         name,
-			PHPType:      name, // TODO: Apply proper PHP naming conventions
-			OriginalName: name,
-			Properties:   convertProperties(schema.Properties, schema.Required),
-			Description:  schema.Description,
-			IsEnum:       schema.IsEnum,
-			EnumValues:   schema.EnumValues,
+	for _, name := range names {
+		schema := schemas[name]
+		if converter.variants[name] == nil {
+			schemaModels[name] = converter.schemaModel(name, schema, schema.ClassName)
+			continue
+		}
+		for _, direction := range []string{config.DirectionRequest, config.DirectionResponse} {
+			converter.direction = direction
+			schemaModels[name+"#"+direction] = converter.schemaModel(name, schema, converter.variants[name][direction])
 		}
-		schemaModels[name] = schemaModel
+		converter.direction = ""
 	}
+	linkModels(schemaModels)
+	operations := converter.convertOperations(operationInfos)
+	converter.checkPaginationFile(operations)
+	securitySchemeModels := converter.convertSecuritySchemes(securitySchemes, operations)
+	serverModels := converter.convertServers(servers, operations, securitySchemeModels)
+	g.warnings = append(g.warnings, converter.warnings...)
+	g.deprecations = deprecations(names, schemas, converter.enums, operations)
 
 	// Create internal model
 	internalModel := &config.InternalModel{
@@ -70,8 +220,11 @@ func (g *Generator) Generate() error {
 			Version:     spec.Info.Version,
 			Description: spec.Info.Description,
 		},
-		Schemas: schemaModels,
-		Config:  g.config,
+		Schemas:         schemaModels,
+		Operations:      operations,
+		SecuritySchemes: securitySchemeModels,
+		Servers:         serverModels,
+		Config:          g.config,
 	}
 
 	// Generate PHP code
@@ -82,8 +235,78 @@ func (g *Generator) Generate() error {
 	return nil
 }
 
+// SourceFiles returns the input specification and every local file it references,
+// as read by the last call to Generate, and the pagination file. It is used to
+// decide what to watch.
+func (g *Generator) SourceFiles() []string {
+	files := g.parser.SourceFiles()
+	if g.config.PaginationFile != "" {
+		path, err := filepath.Abs(g.config.PaginationFile)
+		if err != nil {
+			path = g.config.PaginationFile
+		}
+		files = append(files, path)
+	}
+	return files
+}
+
+// Deprecations describes the deprecated schemas, properties, enum values, operations
+// and parameters found by the last call to Generate.
+func (g *Generator) Deprecations() []string {
+	return g.deprecations
+}
+
+// Warnings returns the non-fatal diagnostics collected by the last call to Generate.
+func (g *Generator) Warnings() []string {
+	return append(append([]string(nil), g.warnings...), g.phpGen.Warnings()...)
+}
+
+// Notes returns the informational diagnostics collected by the last call to
+// Generate, such as those of plugins.
+func (g *Generator) Notes() []string {
+	return g.phpGen.Notes()
+}
+
+// Changes returns the changes the last call to Generate made to the output
+// directory, or would have made in a dry run.
+func (g *Generator) Changes() []FileChange {
+	return g.phpGen.Changes()
+}
+
+// schemaModel converts an analyzed schema to the model of the class named className,
+// in the converter's current direction.
+func (c *modelConverter) schemaModel(name string, schema *analyzer.SchemaInfo, className string) *config.SchemaModel {
+	c.current = name
+	model := &config.SchemaModel{
+		Name:         className,
+		PHPType:      className,
+		OriginalName: name,
+		Properties:   c.convertProperties(name, schema.Properties, schema.Required),
+		Description:  schema.Description,
+		IsEnum:       schema.IsEnum,
+		EnumValues:   schema.EnumValues,
+		EnumCases:    c.enums[name],
+		Direction:    c.direction,
+		Deprecated:   schema.Deprecated,
+		ExternalDocs: schema.Schema.ExternalDocs,
+		Recursive:    len(schema.Cycle) > 0,
+	}
+	if !schema.IsEnum {
+		model.DataType = c.dataTypes[className]
+	}
+	return model
+}
+
 // Helper function to convert old properties to new format.
-func convertProperties(oldProps map[string]*openapi3.SchemaRef, required []string) []*config.Property {
+// Properties are sorted by wire name so the output and the name mapping are stable.
+// Request variants leave out readOnly properties and response variants writeOnly
+// ones; a single class used both ways makes them optional, since each is absent in
+// one direction.
+func (c *modelConverter) convertProperties(
+	owner string,
+	oldProps map[string]*openapi3.SchemaRef,
+	required []string,
+) []*config.Property {
 	var properties []*config.Property
 
 	// Create a map for quick required field lookup
@@ -92,12 +315,25 @@ func convertProperties(oldProps map[string]*openapi3.SchemaRef, required []strin
 		requiredMap[reqField] = true
 	}
 
+	wireNames := make([]string, 0, len(oldProps))
 	for name, propRef := range oldProps {
-		if propRef.Value == nil {
+		if propRef.Value == nil ||
+			c.direction == config.DirectionRequest && propRef.Value.ReadOnly ||
+			c.direction == config.DirectionResponse && propRef.Value.WriteOnly {
 			continue
 		}
+		wireNames = append(wireNames, name)
+	}
+	sort.Strings(wireNames)
+	phpNames := naming.PropertyNames(wireNames, c.propertyNaming)
 
-		prop := createPropertyFromRef(name, propRef, requiredMap[name])
+	for _, name := range wireNames {
+		prop := c.createPropertyFromRef(name, oldProps[name], requiredMap[name])
+		prop.PHPName = phpNames[name]
+		if c.direction == "" && (prop.ReadOnly || prop.WriteOnly) {
+			prop.Required = false
+		}
+		c.applyDefault(owner, prop, oldProps[name])
 		properties = append(properties, prop)
 	}
 
@@ -105,32 +341,32 @@ func convertProperties(oldProps map[string]*openapi3.SchemaRef, required []strin
 }
 
 // createPropertyFromRef creates a property from an OpenAPI schema reference.
-func createPropertyFromRef(name string, propRef *openapi3.SchemaRef, isRequired bool) *config.Property {
-	// Handle schema references first
-	var typeName string
-	if propRef.Ref != "" {
-		// Extract schema name from reference
-		parts := strings.Split(propRef.Ref, "/")
-		if len(parts) > 0 {
-			typeName = parts[len(parts)-1]
-		} else {
-			typeName = "mixed"
-		}
-	} else {
-		typeName = mapOpenAPITypeToPHP(propRef.Value)
+func (c *modelConverter) createPropertyFromRef(
+	name string,
+	propRef *openapi3.SchemaRef,
+	isRequired bool,
+) *config.Property {
+	// A reference wrapped in allOf takes the type of the referenced schema
+	typeRef := analyzer.RefTarget(propRef)
+	phpType := c.phpType(typeRef)
+	if typeRef != propRef && isNullable(propRef.Value) {
+		phpType.IsNullable = true
 	}
-
-	phpType := config.PHPType{
-		Name:       typeName,
-		IsNullable: !isRequired, // Required fields are not nullable
-		DocComment: typeName,
+	// Numbers held as strings or BigDecimal objects take their values as strings
+	value := func(number interface{}) interface{} { return normalizeNumber(number, c.integerText) }
+	if phpType.Numeric != "" {
+		value = func(number interface{}) interface{} { return numericString(normalizeNumber(number, c.integerText)) }
 	}
 
-	// Handle array types with proper item type detection
-	if phpType.Name == "array" && propRef.Value.Items != nil {
-		itemType := resolveArrayItemType(propRef.Value.Items)
-		phpType.IsArray = true
-		phpType.DocComment = fmt.Sprintf("array<%s>", itemType)
+	var enumValues []interface{}
+	if typeRef.Ref != "" {
+		if cases, ok := c.enums[analyzer.SchemaNameFromRef(typeRef.Ref)]; ok {
+			enumValues = caseValues(cases)
+		}
+	} else {
+		for _, enumValue := range propRef.Value.Enum {
+			enumValues = append(enumValues, value(enumValue))
+		}
 	}
 
 	return &config.Property{
@@ -139,16 +375,26 @@ func createPropertyFromRef(name string, propRef *openapi3.SchemaRef, isRequired
 		OpenAPIType: propRef.Value,
 		Required:    isRequired,
 		Description: propRef.Value.Description,
+		EnumValues:  enumValues,
+		Default:     value(propRef.Value.Default),
+		Constraints: analyzer.PropertyConstraints(propRef.Value),
+		ReadOnly:    propRef.Value.ReadOnly,
+		WriteOnly:   propRef.Value.WriteOnly,
+		Deprecated:  propRef.Value.Deprecated,
 	}
 }
 
 // Helper function to map OpenAPI types to PHP types.
 func mapOpenAPITypeToPHP(schema *openapi3.Schema) string {
-	if len(schema.Type.Slice()) == 0 {
-		return "mixed"
+	var schemaType string
+	for _, t := range schema.Type.Slice() {
+		// OpenAPI 3.1 expresses nullability as an extra "null" type
+		if t != openapi3.TypeNull {
+			schemaType = t
+			break
+		}
 	}
 
-	schemaType := schema.Type.Slice()[0]
 	switch schemaType {
 	case "string":
 		return "string"
@@ -167,24 +413,23 @@ func mapOpenAPITypeToPHP(schema *openapi3.Schema) string {
 	}
 }
 
-// Helper function to resolve array item types.
-func resolveArrayItemType(itemsRef *openapi3.SchemaRef) string {
-	if itemsRef == nil {
-		return "mixed"
-	}
+// isNullable reports whether a schema allows null, either through the OpenAPI 3.0
+// nullable flag or an OpenAPI 3.1 "null" type.
+func isNullable(schema *openapi3.Schema) bool {
+	return schema.Nullable || schema.Type.Includes(openapi3.TypeNull)
+}
 
-	if itemsRef.Ref != "" {
-		// Extract schema name from reference
-		parts := strings.Split(itemsRef.Ref, "/")
-		if len(parts) > 0 {
-			return parts[len(parts)-1]
-		}
-		return "mixed"
+// refClassName returns the PHP class name for a schema reference. A split schema
+// resolves to its variant in the current direction, which is always set since only
+// split schemas reference them. References to schemas outside components, e.g. in
+// another file, get the same case conversion.
+func (c *modelConverter) refClassName(ref string) string {
+	name := analyzer.SchemaNameFromRef(ref)
+	if className, ok := c.variants[name][c.direction]; ok {
+		return className
 	}
-
-	if itemsRef.Value != nil {
-		return mapOpenAPITypeToPHP(itemsRef.Value)
+	if className, ok := c.classNames[name]; ok {
+		return className
 	}
-
-	return "mixed"
+	return naming.Pascal(name)
 }
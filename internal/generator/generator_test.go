@@ -0,0 +1,422 @@
+package generator_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/floriscornel/piak/internal/generator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// generate generates the code of a spec into a temporary directory, which it
+// returns with the generator.
+func generate(t *testing.T, spec string, cfg config.GeneratorConfig) (*generator.Generator, string) {
+	t.Helper()
+	dir := t.TempDir()
+	cfg.InputFile = filepath.Join(dir, "openapi.yaml")
+	cfg.OutputDir = filepath.Join(dir, "out")
+	cfg.Namespace = "App"
+	require.NoError(t, os.WriteFile(cfg.InputFile, []byte(spec), 0o600))
+
+	gen, err := generator.NewGenerator(&cfg)
+	require.NoError(t, err)
+	require.NoError(t, gen.Generate())
+	return gen, cfg.OutputDir
+}
+
+// readFile returns the contents of a generated file.
+func readFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	require.NoError(t, err)
+	return string(data)
+}
+
+const largeIntegerSpec = `openapi: 3.0.3
+info: {title: Counters, version: "1"}
+paths: {}
+components:
+  schemas:
+    Counter:
+      type: object
+      properties:
+        exact: {type: integer, format: int64, default: 9007199254740993}
+        negative: {type: integer, format: int64, default: -9007199254740993}
+        overflow: {type: integer, default: 99999999999999999999}
+`
+
+func TestGenerate_LargeIntegerDefaults(t *testing.T) {
+	gen, dir := generate(t, largeIntegerSpec, config.GeneratorConfig{})
+	model := readFile(t, dir, "src/Counter.php")
+	assert.Contains(t, model, "public int $exact = 9007199254740993,")
+	assert.Contains(t, model, "public int $negative = -9007199254740993,")
+	assert.Contains(t, model, "array_key_exists('exact', $data) ? $data['exact'] : 9007199254740993,")
+	assert.Contains(t, model, "@param int $exact Defaults to 9007199254740993.")
+	assert.NotContains(t, model, "e+15")
+	assert.Contains(t, gen.Warnings(), `schema "Counter": ignoring default of property "overflow": `+
+		"unsupported integer 99999999999999999999, it does not fit in an int")
+
+	gen, dir = generate(t, largeIntegerSpec, config.GeneratorConfig{Int64: config.Int64String})
+	model = readFile(t, dir, "src/Counter.php")
+	assert.Contains(t, model, "public string $exact = '9007199254740993',")
+	assert.Contains(t, model, "public string $negative = '-9007199254740993',")
+	assert.Len(t, gen.Warnings(), 1)
+}
+
+func TestGenerate_DecimalDefaults(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: Prices, version: "1"}
+paths: {}
+components:
+  schemas:
+    Price:
+      type: object
+      properties:
+        amount: {type: string, format: decimal, default: "1.50"}
+`
+	gen, dir := generate(t, spec, config.GeneratorConfig{Decimal: config.DecimalString})
+	assert.Contains(t, readFile(t, dir, "src/Price.php"), "public string $amount = '1.50',")
+	assert.Empty(t, gen.Warnings())
+
+	gen, _ = generate(t, spec, config.GeneratorConfig{Decimal: config.DecimalBigDecimal})
+	assert.Equal(t, []string{`schema "Price": ignoring default of property "amount": ` +
+		"PHP has no constant expression for a BigDecimal, pass it to the constructor"}, gen.Warnings())
+}
+
+func TestGenerate_WrappedReferenceDefaults(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: Items, version: "1"}
+paths: {}
+components:
+  schemas:
+    Status: {type: string, enum: [active, retired]}
+    Owner: {type: object, properties: {name: {type: string}}}
+    Item:
+      type: object
+      properties:
+        status: {allOf: [{$ref: '#/components/schemas/Status'}], default: active}
+        owner: {allOf: [{$ref: '#/components/schemas/Owner'}], nullable: true}
+`
+	gen, dir := generate(t, spec, config.GeneratorConfig{})
+	model := readFile(t, dir, "src/Item.php")
+	assert.Contains(t, model, "public Status $status = Status::Active,")
+	assert.Contains(t, model, "array_key_exists('status', $data) ? Status::from($data['status']) : Status::Active,")
+	assert.Contains(t, model, "public Owner|Undefined|null $owner = Undefined::Value,")
+	assert.Empty(t, gen.Warnings())
+}
+
+func TestGenerate_RawRequestBodies(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: Files, version: "1"}
+paths:
+  /files:
+    put:
+      operationId: putFile
+      requestBody:
+        required: true
+        content:
+          application/octet-stream: {schema: {type: string, format: binary}}
+      responses: {'204': {description: Stored}}
+  /blobs:
+    post:
+      operationId: postBlob
+      requestBody:
+        content:
+          '*/*': {schema: {type: string, format: binary}}
+      responses: {'204': {description: Stored}}
+  /images:
+    post:
+      operationId: postImage
+      requestBody:
+        content:
+          image/png: {schema: {type: string, format: binary}}
+      responses: {'204': {description: Stored}}
+components:
+  schemas:
+    File: {type: object, properties: {name: {type: string}}}
+`
+	gen, dir := generate(t, spec, config.GeneratorConfig{GenerateClient: true})
+	client := readFile(t, dir, "src/ApiClient.php")
+	assert.Contains(t, client, "public function putFile(mixed $body, array $data = [], array $headers = []): array")
+	assert.Contains(t, client, "public function postBlob(mixed $body = null, array $data = [], array $headers = [])")
+	assert.Contains(t, client, "$headers, contentType: 'application/octet-stream', body: $body);")
+	assert.Equal(t, []string{
+		`operation "postImage" has a request body of unsupported media types image/png, sending JSON`,
+	}, gen.Warnings())
+}
+
+func TestGenerate_Pagination(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: Pets, version: "1"}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      x-pagination: {style: cursor, items: data, nextCursor: meta.next, pageSize: 50}
+      responses:
+        '200':
+          description: A page of pets
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  data: {type: array, items: {$ref: '#/components/schemas/Pet'}}
+  /owners:
+    get:
+      operationId: listOwners
+      x-pagination: {style: offset}
+      responses:
+        '200':
+          description: A page of owners
+          content:
+            application/json:
+              schema: {type: array, items: {type: string}}
+components:
+  schemas:
+    Pet: {type: object, properties: {name: {type: string}}}
+`
+	gen, dir := generate(t, spec, config.GeneratorConfig{GenerateClient: true})
+	client := readFile(t, dir, "src/ApiClient.php")
+	assert.Contains(t, client, "public function listPetsIterator(")
+	assert.Contains(t, client, "'nextCursor' => 'meta.next'")
+	assert.NotContains(t, client, "listOwnersIterator")
+	assert.Equal(t, []string{
+		`operation "listOwners" has offset pagination without items, the path of the item list in the response ` +
+			"body, generating no iterator",
+		`operation "listPets": ignoring unknown pagination keys pageSize`,
+	}, gen.Warnings())
+}
+
+func TestNormalizeNumber(t *testing.T) {
+	integerText := func(f float64) (string, bool) {
+		if f == 1<<53 {
+			return "9007199254740992", true
+		}
+		return "", false
+	}
+	tests := []struct {
+		value    any
+		expected any
+	}{
+		{float64(0), 0},
+		{float64(1<<53 - 1), 1<<53 - 1},
+		{-float64(1<<53 - 1), -(1<<53 - 1)},
+		{float64(1 << 53), json.Number("9007199254740992")},
+		{-float64(1 << 53), -float64(1 << 53)},
+		{1e21, 1e21},
+		{1.5, 1.5},
+		{"1", "1"},
+		{nil, nil},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, generator.NormalizeNumber(tt.value, integerText), "%v", tt.value)
+	}
+}
+
+func TestGenerate_Operations(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: Mail, version: "1"}
+paths:
+  /send:
+    post:
+      operationId: send
+      x-retryable: sometimes
+      responses: {'204': {description: Sent}}
+  /drafts:
+    get:
+      operationId: send_2
+      x-retryable: true
+      responses: {'204': {description: Listed}}
+components:
+  schemas:
+    Mail: {type: object, properties: {to: {type: string}}}
+`
+	gen, dir := generate(t, spec, config.GeneratorConfig{GenerateClient: true})
+	client := readFile(t, dir, "src/ApiClient.php")
+	assert.Contains(t, client, "public function send2(array $data = [], array $headers = []): array\n    {\n"+
+		"        return $this->request('GET', '/drafts', $data, $headers, retryable: true);")
+	assert.Contains(t, client, "public function send3(array $data = [], array $headers = []): array\n    {\n"+
+		"        return $this->request('POST', '/send', $data, $headers);")
+	assert.Equal(t, []string{
+		`operation "send" would be client method send, which is already taken, generating send3 instead`,
+		`operation "send" has x-retryable sometimes, expected true or false, ignoring it`,
+	}, gen.Warnings())
+}
+
+func TestGenerate_RequestBodies(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: Uploads, version: "1"}
+paths:
+  /messages:
+    post:
+      operationId: postMessage
+      requestBody:
+        content:
+          application/x-www-form-urlencoded: {schema: {$ref: '#/components/schemas/Message'}}
+          application/json: {schema: {$ref: '#/components/schemas/Message'}}
+      responses: {'204': {description: Sent}}
+  /uploads:
+    post:
+      operationId: upload
+      requestBody:
+        content:
+          application/x-www-form-urlencoded: {schema: {type: object}}
+          multipart/form-data:
+            schema:
+              type: object
+              properties:
+                name: {type: string}
+                file: {type: string, format: binary}
+                extras: {type: array, items: {type: string, format: binary}}
+            encoding:
+              file: {contentType: image/png}
+      responses: {'204': {description: Uploaded}}
+components:
+  schemas:
+    Message: {type: object, properties: {to: {type: string}}}
+`
+	gen, dir := generate(t, spec, config.GeneratorConfig{GenerateClient: true})
+	client := readFile(t, dir, "src/ApiClient.php")
+	assert.Contains(t, client, "return $this->request('POST', '/messages', $data, $headers);")
+	assert.Contains(t, client, "contentType: 'multipart/form-data',")
+	assert.Contains(t, client, "'extras' => ['file' => true],")
+	assert.Contains(t, client, "'file' => ['contentType' => 'image/png', 'file' => true],")
+	assert.NotContains(t, client, "'name' =>")
+	assert.Empty(t, gen.Warnings())
+}
+
+func TestGenerate_Servers(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: Shop, version: "1"}
+servers:
+  - url: https://{region}.shop.test/{httpClient}
+    description: Send
+    variables:
+      region: {default: eu, enum: [eu, us]}
+      httpClient: {default: a}
+  - url: https://sandbox.shop.test
+paths:
+  /send:
+    post:
+      operationId: send
+      servers: [{url: https://uploads.shop.test}]
+      responses: {'204': {description: Sent}}
+components:
+  schemas:
+    Order: {type: object, properties: {id: {type: string}}}
+`
+	gen, dir := generate(t, spec, config.GeneratorConfig{GenerateClient: true})
+	client := readFile(t, dir, "src/ApiClient.php")
+	assert.Contains(t, client, "'send' => [\n            'url' => 'https://{region}.shop.test/{httpClient}',")
+	assert.Contains(t, client, "'server2' => [\n            'url' => 'https://sandbox.shop.test',")
+	assert.Contains(t, client, "public static function send3(\n        string $region = 'eu',\n"+
+		"        string $serverHttpClient = 'a',")
+	assert.Contains(t, client, "public static function server2(")
+	assert.Contains(t, client, "'server1' => [\n                'url' => 'https://uploads.shop.test',")
+	assert.Contains(t, client, "$this->operationServer('send') . '/send'")
+	assert.Equal(t, []string{
+		`operation "send" would be client method send, which is already taken, generating send2 instead`,
+		`server "https://{region}.shop.test/{httpClient}" would have constructor send, which is already taken, ` +
+			"generating send3 instead",
+	}, gen.Warnings())
+}
+
+func TestGenerate_ManifestDeletesStaleFiles(t *testing.T) {
+	spec := `openapi: 3.0.3
+info: {title: Pets, version: "1"}
+paths: {}
+components:
+  schemas:
+    Pet: {type: object, properties: {name: {type: string}}}
+    Toy: {type: object, properties: {name: {type: string}}}
+`
+	dir := t.TempDir()
+	cfg := config.GeneratorConfig{
+		InputFile: filepath.Join(dir, "openapi.yaml"),
+		OutputDir: filepath.Join(dir, "out"),
+		Namespace: "App",
+	}
+	run := func(spec string, dryRun bool) []generator.FileChange {
+		t.Helper()
+		require.NoError(t, os.WriteFile(cfg.InputFile, []byte(spec), 0o600))
+		cfg.DryRun = dryRun
+		gen, err := generator.NewGenerator(&cfg)
+		require.NoError(t, err)
+		require.NoError(t, gen.Generate())
+		return gen.Changes()
+	}
+
+	run(spec, false)
+	require.FileExists(t, filepath.Join(cfg.OutputDir, "src/Toy.php"))
+	assert.Empty(t, run(spec, true), "an up to date output has no changes")
+
+	// Files the user added are kept, files no longer generated are deleted
+	require.NoError(t, os.WriteFile(filepath.Join(cfg.OutputDir, "src/Custom.php"), []byte("<?php\n"), 0o600))
+	withoutToy := strings.Replace(spec, "    Toy: {type: object, properties: {name: {type: string}}}\n", "", 1)
+	assert.Equal(t, []generator.FileChange{
+		{Path: "openapi.yaml", Action: generator.ChangeUpdate},
+		{Path: filepath.Join("src", "Toy.php"), Action: generator.ChangeDelete},
+	}, run(withoutToy, true))
+	assert.FileExists(t, filepath.Join(cfg.OutputDir, "src/Toy.php"), "a dry run writes nothing")
+
+	run(withoutToy, false)
+	assert.NoFileExists(t, filepath.Join(cfg.OutputDir, "src/Toy.php"))
+	assert.FileExists(t, filepath.Join(cfg.OutputDir, "src/Custom.php"))
+	assert.Contains(t, readFile(t, cfg.OutputDir, generator.ManifestFile), `"src/Pet.php"`)
+}
+
+func TestGenerate_PluginDiagnosticsBySeverity(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugins are not supported on Windows")
+	}
+	bin := t.TempDir()
+	script := `#!/bin/sh
+cat > /dev/null
+printf '{"files":[{"path":"SRC/pet.php","content":""}],"diagnostics":['
+printf '{"severity":"warning","message":"experimental"},{"severity":"info","message":"2 services"}]}'
+`
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "piak-gen-notes"), []byte(script), 0o755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	spec := `openapi: 3.0.3
+info: {title: Pets, version: "1"}
+paths: {}
+components:
+  schemas:
+    Pet: {type: object, properties: {name: {type: string}}}
+`
+	dir := t.TempDir()
+	cfg := &config.GeneratorConfig{
+		InputFile: filepath.Join(dir, "openapi.yaml"),
+		OutputDir: filepath.Join(dir, "out"),
+		Namespace: "App",
+		Plugins:   []string{"notes"},
+	}
+	require.NoError(t, os.WriteFile(cfg.InputFile, []byte(spec), 0o600))
+	gen, err := generator.NewGenerator(cfg)
+	require.NoError(t, err)
+
+	// Files differing from built-in ones only in case would overwrite them on some file systems
+	err = gen.Generate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "file SRC/pet.php conflicts with another generated file")
+
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "piak-gen-notes"),
+		[]byte(strings.Replace(script, "SRC/pet.php", generator.ManifestFile, 1)), 0o755))
+	err = gen.Generate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "file .piak-manifest.json is the manifest of the generated files")
+
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "piak-gen-notes"),
+		[]byte(strings.Replace(script, "SRC/pet.php", "src/Rpc.php", 1)), 0o755))
+	require.NoError(t, gen.Generate())
+	assert.Equal(t, []string{"plugin notes: experimental"}, gen.Warnings())
+	assert.Equal(t, []string{"plugin notes: 2 services"}, gen.Notes())
+}
@@ -0,0 +1,203 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/floriscornel/piak/internal/analyzer"
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/floriscornel/piak/internal/naming"
+	"github.com/floriscornel/piak/internal/templates"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// clientMethodNames are the methods of the generated client that operation methods
+// must not take.
+var clientMethodNames = []string{
+	"__construct", "request", "requestStream", "requestLines", "requestEvents", "readLines", "discoverHttpClient",
+	"send", "sendWithRetries", "decodeResponse", "decodeError", "formBody", "multipartBody", "writePart",
+	"serializeParameter", "parameterString", "paginate", "extract", "nextLink", "applySecurity", "fetchAccessToken",
+	"forServer", "expandServerUrl", "operationServer",
+}
+
+// retryableExtension marks an operation as safe to retry although its method is not
+// idempotent, or, set to false, as unsafe to retry although it is.
+const retryableExtension = "x-retryable"
+
+// convertOperations converts analyzed operations to the internal model format.
+// Each operation gets a client method name in camelCase, unique ignoring case as
+// PHP method names are, and so does the iterator of a paginated operation.
+func (c *modelConverter) convertOperations(operations []*analyzer.OperationInfo) []*config.OperationModel {
+	models := make([]*config.OperationModel, 0, len(operations))
+
+	taken := make(map[string]bool)
+	for _, name := range clientMethodNames {
+		taken[strings.ToLower(name)] = true
+	}
+
+	for _, op := range operations {
+		base := naming.Camel(op.OperationID)
+		methodName := base
+		for n := 2; taken[strings.ToLower(methodName)]; n++ {
+			methodName = base + strconv.Itoa(n)
+		}
+		if methodName != base {
+			c.warnings = append(c.warnings, fmt.Sprintf(
+				"operation %q would be client method %s, which is already taken, generating %s instead",
+				op.OperationID, base, methodName))
+		}
+		taken[strings.ToLower(methodName)] = true
+
+		models = append(models, &config.OperationModel{
+			OperationID: op.OperationID,
+			MethodName:  methodName,
+			Method:      op.Method,
+			Path:        op.Path,
+			Summary:     op.Operation.Summary,
+			Description: op.Operation.Description,
+			Tags:        op.Operation.Tags,
+			Deprecated:  op.Operation.Deprecated,
+			Parameters:  c.convertParameters(op),
+			Security:    c.convertSecurity(op),
+
+			ErrorResponses: c.convertErrorResponses(op),
+			Retryable:      c.retryable(op),
+			Pagination:     c.convertPagination(op),
+			RequestBody:    c.convertRequestBody(op),
+			Response:       c.convertResponse(op),
+			Servers:        convertServerList(op.Servers),
+		})
+	}
+
+	// Iterators are named after their operation method once all of those are taken
+	for i, model := range models {
+		if model.Pagination == nil {
+			continue
+		}
+		if model.Response != nil && model.Response.Kind != config.ResponseJSON {
+			c.warnings = append(c.warnings, fmt.Sprintf(
+				"operation %q has pagination but no JSON response, generating no iterator", model.OperationID))
+			model.Pagination = nil
+			continue
+		}
+		base := model.MethodName + "Iterator"
+		name := base
+		for n := 2; taken[strings.ToLower(name)]; n++ {
+			name = base + strconv.Itoa(n)
+		}
+		if name != base {
+			c.warnings = append(c.warnings, fmt.Sprintf(
+				"operation %q would have iterator %s, which is already taken, generating %s instead",
+				operations[i].OperationID, base, name))
+		}
+		taken[strings.ToLower(name)] = true
+		model.Pagination.IteratorName = name
+	}
+
+	return models
+}
+
+// retryable returns the x-retryable extension of an operation, or nil when it has
+// none and retrying is decided by its method.
+func (c *modelConverter) retryable(op *analyzer.OperationInfo) *bool {
+	extension, ok := op.Operation.Extensions[retryableExtension]
+	if !ok {
+		return nil
+	}
+	retryable, ok := extension.(bool)
+	if !ok {
+		c.warnings = append(c.warnings, fmt.Sprintf(
+			"operation %q has %s %v, expected true or false, ignoring it", op.OperationID, retryableExtension, extension))
+		return nil
+	}
+	return &retryable
+}
+
+// convertErrorResponses converts the error responses of an operation. Bodies
+// referencing a model schema are hydrated into its class.
+func (c *modelConverter) convertErrorResponses(op *analyzer.OperationInfo) []*config.ErrorResponseModel {
+	responses := make([]*config.ErrorResponseModel, 0, len(op.ErrorResponses))
+	for _, response := range op.ErrorResponses {
+		model := &config.ErrorResponseModel{
+			Status:      response.Status,
+			Description: response.Description,
+			Exception:   templates.ExceptionClass(response.Status),
+			ProblemJSON: response.ProblemJSON,
+		}
+		if response.SchemaName != "" {
+			model.ClassName = c.responseClass(response.SchemaName)
+			model.DataType = c.dataTypes[model.ClassName]
+		}
+		responses = append(responses, model)
+	}
+	return responses
+}
+
+// responseClass returns the model class response bodies of a schema are hydrated
+// into: the response variant for split schemas, and none for enum schemas, which
+// have no fromArray, or for unknown ones.
+func (c *modelConverter) responseClass(name string) string {
+	if c.enums[name] != nil {
+		return ""
+	}
+	if variants := c.variants[name]; variants != nil {
+		return variants[config.DirectionResponse]
+	}
+	return c.classNames[name]
+}
+
+// convertParameters converts the parameters of an operation. Serialization styles
+// the parameter's location does not support are reported and replaced by its default.
+func (c *modelConverter) convertParameters(op *analyzer.OperationInfo) []*config.ParameterModel {
+	parameters := make([]*config.ParameterModel, 0, len(op.Parameters))
+	for _, paramRef := range op.Parameters {
+		param := paramRef.Value
+		model := &config.ParameterModel{
+			Name:        param.Name,
+			In:          param.In,
+			Description: param.Description,
+			Required:    param.Required,
+			Deprecated:  param.Deprecated,
+		}
+		if param.Schema != nil {
+			model.OpenAPIType = param.Schema.Value
+		}
+		if method, err := param.SerializationMethod(); err == nil {
+			model.Style, model.Explode = method.Style, method.Explode
+		}
+		if !supportsStyle(model.In, model.Style, model.Explode) {
+			defaults := &openapi3.Parameter{In: param.In}
+			method, _ := defaults.SerializationMethod()
+			if method != nil {
+				c.warnings = append(c.warnings, fmt.Sprintf(
+					"%s parameter %q of operation %q does not support style %q with explode %t, using %q",
+					param.In, param.Name, op.OperationID, model.Style, model.Explode, method.Style))
+				model.Style, model.Explode = method.Style, method.Explode
+			}
+		}
+		parameters = append(parameters, model)
+	}
+	return parameters
+}
+
+// parameterStyles are the serialization styles each parameter location supports.
+var parameterStyles = map[string][]string{
+	openapi3.ParameterInPath:   {openapi3.SerializationSimple, openapi3.SerializationLabel, openapi3.SerializationMatrix},
+	openapi3.ParameterInHeader: {openapi3.SerializationSimple},
+	openapi3.ParameterInCookie: {openapi3.SerializationForm},
+	openapi3.ParameterInQuery: {
+		openapi3.SerializationForm, openapi3.SerializationSpaceDelimited, openapi3.SerializationPipeDelimited,
+		openapi3.SerializationDeepObject,
+	},
+}
+
+// supportsStyle reports whether parameters of a location can be serialized with a
+// style; deepObject requires explode.
+func supportsStyle(in, style string, explode bool) bool {
+	if style == openapi3.SerializationDeepObject && !explode {
+		return false
+	}
+	return slices.Contains(parameterStyles[in], style)
+}
@@ -0,0 +1,201 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ManifestFile lists the files of the last generation in the output directory, so
+// the next one can delete those it no longer generates. Files it does not list are
+// never deleted.
+const ManifestFile = ".piak-manifest.json"
+
+// manifest is the content of ManifestFile.
+type manifest struct {
+	Files []string `json:"files"` // slash-separated, relative to the output directory
+}
+
+// Actions of a FileChange.
+const (
+	ChangeCreate = "create"
+	ChangeUpdate = "update"
+	ChangeDelete = "delete"
+)
+
+// FileChange is a change generation makes, or would make in a dry run, to a file in
+// the output directory.
+type FileChange struct {
+	Path   string // relative to the output directory
+	Action string
+}
+
+// outputFile is a rendered file waiting to be written to the output directory.
+type outputFile struct {
+	Path    string // relative to the output directory
+	Content []byte
+}
+
+// addFile queues a rendered file for writing.
+func (g *PHPGenerator) addFile(path string, content []byte) {
+	g.files = append(g.files, outputFile{Path: path, Content: content})
+}
+
+// hasFile reports whether a file with the given path is already queued. Paths are
+// compared ignoring case, as two files differing only in case would overwrite each
+// other on case-insensitive file systems.
+func (g *PHPGenerator) hasFile(path string) bool {
+	for _, file := range g.files {
+		if strings.EqualFold(file.Path, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Changes returns the changes the last generation made to the output directory, or
+// would have made in a dry run, sorted by path.
+func (g *PHPGenerator) Changes() []FileChange {
+	return g.changes
+}
+
+// writeFiles writes the queued files that changed to the output directory, deletes
+// the files of the previous generation that are no longer generated and records the
+// files in the manifest. In a dry run, it only records the changes.
+func (g *PHPGenerator) writeFiles() error {
+	changes, err := g.planChanges()
+	if err != nil {
+		return err
+	}
+	g.changes = changes
+	if g.config.DryRun {
+		return nil
+	}
+
+	if err := g.createDirectoryStructure(); err != nil {
+		return fmt.Errorf("failed to create directory structure: %w", err)
+	}
+
+	contents := make(map[string][]byte, len(g.files))
+	for _, file := range g.files {
+		contents[file.Path] = file.Content
+	}
+	for _, change := range changes {
+		filePath := filepath.Join(g.config.OutputDir, change.Path)
+		if change.Action == ChangeDelete {
+			if err := os.Remove(filePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("failed to delete stale file %s: %w", filePath, err)
+			}
+			g.removeEmptyDirs(filepath.Dir(filePath))
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", change.Path, err)
+		}
+		if err := os.WriteFile(filePath, contents[change.Path], 0644); err != nil {
+			return fmt.Errorf("failed to write file %s: %w", filePath, err)
+		}
+	}
+
+	return g.writeManifest()
+}
+
+// planChanges compares the queued files with the output directory: files that are
+// missing are created, files whose content differs are updated, and files of the
+// previous manifest that are no longer generated are deleted.
+func (g *PHPGenerator) planChanges() ([]FileChange, error) {
+	var changes []FileChange
+	generated := make(map[string]bool, len(g.files))
+	for _, file := range g.files {
+		generated[strings.ToLower(filepath.ToSlash(file.Path))] = true
+
+		existing, err := os.ReadFile(filepath.Join(g.config.OutputDir, file.Path))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			changes = append(changes, FileChange{Path: file.Path, Action: ChangeCreate})
+		case err != nil:
+			return nil, fmt.Errorf("failed to read %s: %w", file.Path, err)
+		case !bytes.Equal(existing, file.Content):
+			changes = append(changes, FileChange{Path: file.Path, Action: ChangeUpdate})
+		}
+	}
+
+	previous, err := g.readManifest()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range previous.Files {
+		if generated[strings.ToLower(path)] {
+			continue
+		}
+		filePath := filepath.FromSlash(path)
+		if _, err := os.Stat(filepath.Join(g.config.OutputDir, filePath)); err == nil {
+			changes = append(changes, FileChange{Path: filePath, Action: ChangeDelete})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// readManifest reads the manifest of the previous generation, which is empty when
+// there is none. Paths that are absolute or leave the output directory are dropped,
+// so an edited manifest cannot delete files outside it.
+func (g *PHPGenerator) readManifest() (*manifest, error) {
+	content, err := os.ReadFile(filepath.Join(g.config.OutputDir, ManifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return &manifest{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ManifestFile, err)
+	}
+
+	var previous manifest
+	if err := json.Unmarshal(content, &previous); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ManifestFile, err)
+	}
+	files := previous.Files[:0]
+	for _, path := range previous.Files {
+		if filepath.IsLocal(filepath.FromSlash(path)) {
+			files = append(files, path)
+		}
+	}
+	previous.Files = files
+	return &previous, nil
+}
+
+// writeManifest records the queued files in the manifest.
+func (g *PHPGenerator) writeManifest() error {
+	current := manifest{Files: make([]string, 0, len(g.files))}
+	for _, file := range g.files {
+		current.Files = append(current.Files, filepath.ToSlash(file.Path))
+	}
+	sort.Strings(current.Files)
+
+	content, err := json.MarshalIndent(current, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", ManifestFile, err)
+	}
+	manifestPath := filepath.Join(g.config.OutputDir, ManifestFile)
+	if err := os.WriteFile(manifestPath, append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", manifestPath, err)
+	}
+	return nil
+}
+
+// removeEmptyDirs removes dir and its parents up to the output directory as long as
+// they are empty, after a stale file in them was deleted.
+func (g *PHPGenerator) removeEmptyDirs(dir string) {
+	root := filepath.Clean(g.config.OutputDir)
+	for dir = filepath.Clean(dir); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			return
+		}
+	}
+}
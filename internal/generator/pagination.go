@@ -0,0 +1,175 @@
+package generator
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/floriscornel/piak/internal/analyzer"
+	"github.com/floriscornel/piak/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// paginationExtension describes how an operation pages its results, see
+// paginationSpec for its fields.
+const paginationExtension = "x-pagination"
+
+// paginationSpec is an x-pagination extension or an entry of the pagination file.
+// Fields left empty take the defaults of the style, see config.PaginationModel.
+// Unknown holds the keys that are none of the fields, most likely misspelled.
+type paginationSpec struct {
+	Style       string   `json:"style"`
+	Items       string   `json:"items"`
+	CursorParam string   `json:"cursorParam"`
+	NextCursor  string   `json:"nextCursor"`
+	OffsetParam string   `json:"offsetParam"`
+	LimitParam  string   `json:"limitParam"`
+	Total       string   `json:"total"`
+	Unknown     []string `json:"-"`
+}
+
+// loadPaginationFile reads a pagination file, which maps operation IDs to their
+// pagination. YAML being a superset of JSON, it may be either.
+func loadPaginationFile(path string) (map[string]*paginationSpec, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pagination file: %w", err)
+	}
+
+	var entries map[string]any
+	if err := yaml.Unmarshal(content, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse pagination file %s: %w", path, err)
+	}
+	specs := make(map[string]*paginationSpec, len(entries))
+	for id, entry := range entries {
+		spec, err := decodePaginationSpec(entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse pagination of %q in %s: %w", id, path, err)
+		}
+		specs[id] = spec
+	}
+	return specs, nil
+}
+
+// decodePaginationSpec decodes a generically decoded pagination description into
+// its fields, recording the keys that are none of them.
+func decodePaginationSpec(value any) (*paginationSpec, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode pagination: %w", err)
+	}
+
+	spec := &paginationSpec{}
+	if err := json.Unmarshal(raw, spec); err != nil {
+		return nil, err
+	}
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool)
+	for _, field := range reflect.VisibleFields(reflect.TypeOf(*spec)) {
+		known[field.Tag.Get("json")] = true
+	}
+	for key := range keys {
+		if !known[key] {
+			spec.Unknown = append(spec.Unknown, key)
+		}
+	}
+	sort.Strings(spec.Unknown)
+	return spec, nil
+}
+
+// convertPagination returns the pagination of an operation, described by the
+// pagination file or else by its x-pagination extension, or nil when it has none.
+// Unknown keys are reported; descriptions with an unknown style, or without the
+// path of the items for the cursor and offset styles, are reported and ignored.
+func (c *modelConverter) convertPagination(op *analyzer.OperationInfo) *config.PaginationModel {
+	spec := c.pagination[op.OperationID]
+	if spec == nil {
+		extension, ok := op.Operation.Extensions[paginationExtension]
+		if !ok {
+			return nil
+		}
+		// The extension has been decoded generically, so it is decoded again into its fields
+		var err error
+		if spec, err = decodePaginationSpec(extension); err != nil {
+			c.warnings = append(c.warnings, fmt.Sprintf(
+				"operation %q has an invalid %s, generating no iterator: %v", op.OperationID, paginationExtension, err))
+			return nil
+		}
+	}
+	if len(spec.Unknown) > 0 {
+		c.warnings = append(c.warnings, fmt.Sprintf("operation %q: ignoring unknown pagination keys %s",
+			op.OperationID, strings.Join(spec.Unknown, ", ")))
+	}
+
+	model := &config.PaginationModel{Style: spec.Style, Items: spec.Items}
+	switch spec.Style {
+	case config.PaginationCursor:
+		model.CursorParam = cmp.Or(spec.CursorParam, "cursor")
+		model.NextCursor = cmp.Or(spec.NextCursor, "next_cursor")
+	case config.PaginationOffset:
+		model.OffsetParam = cmp.Or(spec.OffsetParam, "offset")
+		model.LimitParam = cmp.Or(spec.LimitParam, "limit")
+		model.Total = spec.Total
+	case config.PaginationLink:
+	default:
+		c.warnings = append(c.warnings, fmt.Sprintf(
+			"operation %q has pagination style %q, expected %q, %q or %q, generating no iterator",
+			op.OperationID, spec.Style, config.PaginationCursor, config.PaginationOffset, config.PaginationLink))
+		return nil
+	}
+	if spec.Items == "" && spec.Style != config.PaginationLink {
+		c.warnings = append(c.warnings, fmt.Sprintf(
+			"operation %q has %s pagination without items, the path of the item list in the response body, "+
+				"generating no iterator", op.OperationID, spec.Style))
+		return nil
+	}
+
+	model.ItemClass = c.paginationItemClass(op, spec.Items)
+	model.ItemDataType = c.dataTypes[model.ItemClass]
+	return model
+}
+
+// checkPaginationFile reports the operations of the pagination file that the spec
+// does not have, which are most likely misspelled.
+func (c *modelConverter) checkPaginationFile(operations []*config.OperationModel) {
+	known := make(map[string]bool, len(operations))
+	for _, op := range operations {
+		known[op.OperationID] = true
+	}
+	ids := make([]string, 0, len(c.pagination))
+	for id := range c.pagination {
+		if !known[id] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		c.warnings = append(c.warnings, fmt.Sprintf("pagination file describes unknown operation %q", id))
+	}
+}
+
+// paginationItemClass returns the model class of the items at the given path of the
+// successful response of an operation, or "" when they are not models.
+func (c *modelConverter) paginationItemClass(op *analyzer.OperationInfo, items string) string {
+	schema := op.ResponseSchema
+	if items != "" {
+		for _, key := range strings.Split(items, ".") {
+			if schema == nil || schema.Value == nil {
+				return ""
+			}
+			schema = schema.Value.Properties[key]
+		}
+	}
+	if schema == nil || schema.Value == nil || !schema.Value.Type.Is("array") ||
+		schema.Value.Items == nil || schema.Value.Items.Ref == "" {
+		return ""
+	}
+	return c.responseClass(analyzer.SchemaNameFromRef(schema.Value.Items.Ref))
+}
@@ -8,6 +8,7 @@ import (
 	"text/template"
 
 	"github.com/floriscornel/piak/internal/config"
+	"github.com/floriscornel/piak/internal/php"
 	"github.com/floriscornel/piak/internal/templates"
 )
 
@@ -15,11 +16,15 @@ import (
 type PHPGenerator struct {
 	config    *config.GeneratorConfig
 	templates *template.Template
+	files     []outputFile
+	changes   []FileChange
+	warnings  []string
+	notes     []string
 }
 
 // NewPHPGenerator creates a new PHPGenerator instance.
 func NewPHPGenerator(cfg *config.GeneratorConfig) (*PHPGenerator, error) {
-	tmpl, err := templates.GetTemplates()
+	tmpl, err := templates.GetTemplates(cfg.TemplatesDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load templates: %w", err)
 	}
@@ -31,11 +36,13 @@ func NewPHPGenerator(cfg *config.GeneratorConfig) (*PHPGenerator, error) {
 }
 
 // GenerateFromModel generates PHP code from the internal model.
+// Files are rendered in memory first and only written once every file rendered
+// successfully, so a failing generation leaves the previous output intact.
 func (g *PHPGenerator) GenerateFromModel(model *config.InternalModel) error {
-	// Create output directory structure
-	if err := g.createDirectoryStructure(); err != nil {
-		return fmt.Errorf("failed to create directory structure: %w", err)
-	}
+	g.files = nil
+	g.changes = nil
+	g.warnings = nil
+	g.notes = nil
 
 	// Copy OpenAPI spec to output directory
 	if err := g.copyOpenAPISpec(); err != nil {
@@ -47,9 +54,22 @@ func (g *PHPGenerator) GenerateFromModel(model *config.InternalModel) error {
 		return fmt.Errorf("failed to generate composer.json: %w", err)
 	}
 
+	// Generate the PHPStan configuration used by composer lint
+	if err := g.generatePHPStanConfig(); err != nil {
+		return fmt.Errorf("failed to generate phpstan.neon.dist: %w", err)
+	}
+
+	// Generate support classes used by the models
+	if err := g.generateSupportClass("undefined.php.tmpl", templates.UndefinedClass); err != nil {
+		return fmt.Errorf("failed to generate %s: %w", templates.UndefinedClass, err)
+	}
+	if err := g.generateSupportClass("validation-exception.php.tmpl", templates.ValidationExceptionClass); err != nil {
+		return fmt.Errorf("failed to generate %s: %w", templates.ValidationExceptionClass, err)
+	}
+
 	// Generate classes for each schema
 	for name, schema := range model.Schemas {
-		if genErr := g.generateClass(name, schema); genErr != nil {
+		if genErr := g.generateClass(schema.Name, schema); genErr != nil {
 			return fmt.Errorf("failed to generate class %s: %w", name, genErr)
 		}
 	}
@@ -59,6 +79,24 @@ func (g *PHPGenerator) GenerateFromModel(model *config.InternalModel) error {
 		if clientErr := g.generateClient(model); clientErr != nil {
 			return fmt.Errorf("failed to generate client: %w", clientErr)
 		}
+		if err := g.generateSupportClass("curl-http-client.php.tmpl", templates.CurlClientClass); err != nil {
+			return fmt.Errorf("failed to generate %s: %w", templates.CurlClientClass, err)
+		}
+		if err := g.generateSupportClass("curl-network-exception.php.tmpl", templates.CurlNetworkExceptionClass); err != nil {
+			return fmt.Errorf("failed to generate %s: %w", templates.CurlNetworkExceptionClass, err)
+		}
+		if err := g.generateSupportClass("retry-policy.php.tmpl", templates.RetryPolicyClass); err != nil {
+			return fmt.Errorf("failed to generate %s: %w", templates.RetryPolicyClass, err)
+		}
+		if err := g.generateSupportClass("problem-details.php.tmpl", templates.ProblemDetailsClass); err != nil {
+			return fmt.Errorf("failed to generate %s: %w", templates.ProblemDetailsClass, err)
+		}
+		if err := g.generateSupportClass("server-sent-event.php.tmpl", templates.ServerSentEventClass); err != nil {
+			return fmt.Errorf("failed to generate %s: %w", templates.ServerSentEventClass, err)
+		}
+		if err := g.generateExceptions(); err != nil {
+			return fmt.Errorf("failed to generate exceptions: %w", err)
+		}
 	}
 
 	// Generate tests if requested
@@ -73,7 +111,23 @@ func (g *PHPGenerator) GenerateFromModel(model *config.InternalModel) error {
 		return fmt.Errorf("failed to generate README: %w", err)
 	}
 
-	return nil
+	// Run external generator plugins
+	if err := g.generatePluginFiles(model); err != nil {
+		return fmt.Errorf("failed to run plugins: %w", err)
+	}
+
+	return g.writeFiles()
+}
+
+// Warnings returns the non-fatal diagnostics collected by the last generation.
+func (g *PHPGenerator) Warnings() []string {
+	return g.warnings
+}
+
+// Notes returns the informational diagnostics collected by the last call to
+// GenerateFromModel, such as those of plugins.
+func (g *PHPGenerator) Notes() []string {
+	return g.notes
 }
 
 // createDirectoryStructure creates the src/ and tests/ directories.
@@ -102,28 +156,41 @@ func (g *PHPGenerator) copyOpenAPISpec() error {
 	}
 
 	// Determine the output filename
-	sourcePath := filepath.Base(g.config.InputFile)
-	destPath := filepath.Join(g.config.OutputDir, sourcePath)
+	destPath := filepath.Base(g.config.InputFile)
 
-	// Write to destination
-	if writeErr := os.WriteFile(destPath, sourceData, 0644); writeErr != nil {
-		return fmt.Errorf("failed to write OpenAPI spec: %w", writeErr)
-	}
+	g.addFile(destPath, sourceData)
 
 	return nil
 }
 
+// clientPackages are the packages the generated client requires.
+var clientPackages = map[string]string{
+	"php-http/discovery": "^1.19",
+	"psr/http-client":    "^1.0",
+	"psr/http-factory":   "^1.0",
+	"psr/http-message":   "^1.1 || ^2.0",
+}
+
 // generateComposerJSON creates a composer.json file for the package.
 func (g *PHPGenerator) generateComposerJSON(model *config.InternalModel) error {
 	// Prepare template context
-	templateData := struct {
-		PackageName   string
-		Description   string
-		JSONNamespace string
-	}{
-		PackageName:   g.generatePackageName(),
-		Description:   g.cleanDescription(model.Info.Description),
-		JSONNamespace: g.prepareJSONNamespace(),
+	templateData := templates.ComposerData{
+		PackageName:    g.generatePackageName(),
+		Description:    g.cleanDescription(model.Info.Description),
+		Namespace:      g.config.Namespace,
+		JSONNamespace:  g.prepareJSONNamespace(),
+		Require:        make(map[string]string),
+		GenerateClient: g.config.GenerateClient,
+	}
+	if g.config.Decimal == config.DecimalBigDecimal {
+		templateData.Require["brick/math"] = "^0.12 || ^0.13"
+	}
+	if g.config.GenerateClient {
+		// The client sends requests through PSR-18, discovering an implementation
+		// when none is injected
+		for name, version := range clientPackages {
+			templateData.Require[name] = version
+		}
 	}
 
 	// Use template to generate content
@@ -133,8 +200,8 @@ func (g *PHPGenerator) generateComposerJSON(model *config.InternalModel) error {
 		return fmt.Errorf("failed to execute composer.json template: %w", err)
 	}
 
-	filePath := filepath.Join(g.config.OutputDir, "composer.json")
-	return os.WriteFile(filePath, []byte(content.String()), 0644)
+	g.addFile("composer.json", []byte(content.String()))
+	return nil
 }
 
 // Helper methods for composer.json generation.
@@ -151,14 +218,12 @@ func (g *PHPGenerator) generatePackageName() string {
 }
 
 func (g *PHPGenerator) prepareJSONNamespace() string {
-	return strings.ReplaceAll(g.config.Namespace, "\\", "\\\\")
+	return strings.Trim(php.JSONString(g.config.Namespace), `"`)
 }
 
 func (g *PHPGenerator) cleanDescription(description string) string {
-	cleaned := strings.ReplaceAll(description, "\n", " ")
-	cleaned = strings.ReplaceAll(cleaned, "\r", " ")
-	cleaned = strings.ReplaceAll(cleaned, "\"", "\\\"")
-	cleaned = strings.TrimSpace(cleaned)
+	// Collapse whitespace so the description fits on one line; escaping is up to the template
+	cleaned := strings.Join(strings.Fields(description), " ")
 	if cleaned == "" {
 		cleaned = "Generated API client"
 	}
@@ -175,12 +240,65 @@ func (g *PHPGenerator) generateClass(name string, schema *config.SchemaModel) er
 
 	// Write to src/ directory
 	filename := fmt.Sprintf("%s.php", name)
-	filePath := filepath.Join(g.config.OutputDir, "src", filename)
+	g.addFile(filepath.Join("src", filename), []byte(content))
+
+	return nil
+}
+
+// generateSupportClass generates a class used by the generated models in the src/ directory.
+func (g *PHPGenerator) generateSupportClass(templateName, className string) error {
+	templateData := templates.SupportClassData{
+		Namespace: g.config.Namespace,
+		ClassName: className,
+	}
 
-	if writeErr := os.WriteFile(filePath, []byte(content), 0644); writeErr != nil {
-		return fmt.Errorf("failed to write file %s: %w", filePath, writeErr)
+	var content strings.Builder
+	if err := g.templates.ExecuteTemplate(&content, templateName, templateData); err != nil {
+		return fmt.Errorf("failed to execute %s template: %w", templateName, err)
 	}
 
+	g.addFile(filepath.Join("src", className+".php"), []byte(content.String()))
+	return nil
+}
+
+// generateExceptions generates the exceptions the client throws for error responses
+// in the src/ directory: ApiException, extended by the client and server error
+// exceptions, which the exceptions of specific status codes extend in turn.
+func (g *PHPGenerator) generateExceptions() error {
+	exceptions := []templates.ExceptionData{
+		{ClassName: templates.APIExceptionClass, StatusExceptions: templates.StatusExceptions},
+		{
+			ClassName: templates.ClientErrorExceptionClass,
+			Parent:    templates.APIExceptionClass,
+			Summary:   "Thrown when the API responds with a client error status (4xx).",
+		},
+		{
+			ClassName: templates.ServerErrorExceptionClass,
+			Parent:    templates.APIExceptionClass,
+			Summary:   "Thrown when the API responds with a server error status (5xx).",
+		},
+	}
+	for _, exception := range templates.StatusExceptions {
+		exceptions = append(exceptions, templates.ExceptionData{
+			ClassName: exception.ClassName,
+			Parent:    templates.ClientErrorExceptionClass,
+			Summary:   fmt.Sprintf("Thrown when the API responds with %d %s.", exception.Status, exception.Reason),
+		})
+	}
+
+	for _, data := range exceptions {
+		data.Namespace = g.config.Namespace
+		templateName := "status-exception.php.tmpl"
+		if data.Parent == "" {
+			templateName = "api-exception.php.tmpl"
+		}
+
+		var content strings.Builder
+		if err := g.templates.ExecuteTemplate(&content, templateName, data); err != nil {
+			return fmt.Errorf("failed to execute %s template for %s: %w", templateName, data.ClassName, err)
+		}
+		g.addFile(filepath.Join("src", data.ClassName+".php"), []byte(content.String()))
+	}
 	return nil
 }
 
@@ -192,11 +310,7 @@ func (g *PHPGenerator) generateClient(model *config.InternalModel) error {
 	}
 
 	filename := "ApiClient.php"
-	filePath := filepath.Join(g.config.OutputDir, "src", filename)
-
-	if writeErr := os.WriteFile(filePath, []byte(content), 0644); writeErr != nil {
-		return fmt.Errorf("failed to write client file: %w", writeErr)
-	}
+	g.addFile(filepath.Join("src", filename), []byte(content))
 
 	return nil
 }
@@ -205,7 +319,11 @@ func (g *PHPGenerator) generateClient(model *config.InternalModel) error {
 func (g *PHPGenerator) generateTests(model *config.InternalModel) error {
 	// Generate model tests
 	for name, schema := range model.Schemas {
-		if err := g.generateModelTest(name, schema); err != nil {
+		// Enums have no behaviour of their own to test
+		if schema.IsEnum {
+			continue
+		}
+		if err := g.generateModelTest(schema.Name, schema); err != nil {
 			return fmt.Errorf("failed to generate test for %s: %w", name, err)
 		}
 	}
@@ -229,18 +347,18 @@ func (g *PHPGenerator) generateTests(model *config.InternalModel) error {
 func (g *PHPGenerator) generateModelTest(name string, schema *config.SchemaModel) error {
 	testContent := g.generateModelTestContent(name, schema)
 	filename := fmt.Sprintf("%sTest.php", name)
-	filePath := filepath.Join(g.config.OutputDir, "tests", filename)
+	g.addFile(filepath.Join("tests", filename), []byte(testContent))
 
-	return os.WriteFile(filePath, []byte(testContent), 0644)
+	return nil
 }
 
 // generateAPIClientTest generates tests for the API client.
 func (g *PHPGenerator) generateAPIClientTest(model *config.InternalModel) error {
 	testContent := g.generateAPIClientTestContent(model)
 	filename := "ApiClientTest.php"
-	filePath := filepath.Join(g.config.OutputDir, "tests", filename)
+	g.addFile(filepath.Join("tests", filename), []byte(testContent))
 
-	return os.WriteFile(filePath, []byte(testContent), 0644)
+	return nil
 }
 
 // generatePHPUnitConfig generates phpunit.xml configuration.
@@ -252,22 +370,36 @@ func (g *PHPGenerator) generatePhpUnitConfig() error {
 		return fmt.Errorf("failed to execute phpunit.xml template: %w", err)
 	}
 
-	filePath := filepath.Join(g.config.OutputDir, "phpunit.xml")
-	return os.WriteFile(filePath, []byte(content.String()), 0644)
+	g.addFile("phpunit.xml", []byte(content.String()))
+	return nil
+}
+
+// generatePHPStanConfig generates phpstan.neon.dist, analysing src/ at level 9.
+func (g *PHPGenerator) generatePHPStanConfig() error {
+	var content strings.Builder
+	if err := g.templates.ExecuteTemplate(&content, "phpstan.neon.tmpl", nil); err != nil {
+		return fmt.Errorf("failed to execute phpstan.neon template: %w", err)
+	}
+
+	g.addFile("phpstan.neon.dist", []byte(content.String()))
+	return nil
 }
 
 func (g *PHPGenerator) generateClassContent(_ string, schema *config.SchemaModel) (string, error) {
-	// Prepare template context
-	templateData := struct {
-		*config.SchemaModel
-		Config *config.GeneratorConfig
-	}{
-		SchemaModel: schema,
-		Config:      g.config,
+	// Enum schemas become backed enums, everything else a model class
+	var content strings.Builder
+	if schema.IsEnum {
+		enumData := templates.NewEnumData(schema, g.config)
+		if err := g.templates.ExecuteTemplate(&content, "enum.php.tmpl", enumData); err != nil {
+			return "", fmt.Errorf("failed to execute enum template: %w", err)
+		}
+		return content.String(), nil
 	}
 
+	// Prepare template context
+	templateData := templates.NewModelData(schema, g.config)
+
 	// Use template to generate content
-	var content strings.Builder
 	err := g.templates.ExecuteTemplate(&content, "model.php.tmpl", templateData)
 	if err != nil {
 		return "", fmt.Errorf("failed to execute model template: %w", err)
@@ -278,13 +410,7 @@ func (g *PHPGenerator) generateClassContent(_ string, schema *config.SchemaModel
 
 func (g *PHPGenerator) generateClientContent(model *config.InternalModel) (string, error) {
 	// Prepare template context
-	templateData := struct {
-		*config.InternalModel
-		Config *config.GeneratorConfig
-	}{
-		InternalModel: model,
-		Config:        g.config,
-	}
+	templateData := templates.NewClientData(model, g.config)
 
 	// Use template to generate content
 	var content strings.Builder
@@ -299,16 +425,9 @@ func (g *PHPGenerator) generateClientContent(model *config.InternalModel) (strin
 // generateModelTestContent creates test content for a model class.
 func (g *PHPGenerator) generateModelTestContent(name string, schema *config.SchemaModel) string {
 	// Prepare template context
-	templateData := struct {
-		ClassName     string
-		VarName       string
-		TestNamespace string
-		UseNamespace  string
-		SpecFilename  string
-		Schema        *config.SchemaModel
-	}{
+	templateData := templates.ModelTestData{
 		ClassName:     name,
-		VarName:       strings.ToLower(name),
+		VarName:       php.Identifier(strings.ToLower(name)),
 		TestNamespace: g.config.Namespace + "\\Tests",
 		UseNamespace:  g.config.Namespace,
 		SpecFilename:  filepath.Base(g.config.InputFile),
@@ -327,16 +446,16 @@ func (g *PHPGenerator) generateModelTestContent(name string, schema *config.Sche
 }
 
 // generateAPIClientTestContent creates test content for the API client.
-func (g *PHPGenerator) generateAPIClientTestContent(_ *config.InternalModel) string {
+func (g *PHPGenerator) generateAPIClientTestContent(model *config.InternalModel) string {
 	// Prepare template context
-	templateData := struct {
-		TestNamespace string
-		UseNamespace  string
-		SpecFilename  string
-	}{
+	templateData := templates.ClientTestData{
 		TestNamespace: g.config.Namespace + "\\Tests",
 		UseNamespace:  g.config.Namespace,
 		SpecFilename:  filepath.Base(g.config.InputFile),
+		Servers:       len(model.Servers) > 0,
+	}
+	for _, op := range model.Operations {
+		templateData.Pagination = templateData.Pagination || op.Pagination != nil
 	}
 
 	// Use template to generate content
@@ -353,12 +472,7 @@ func (g *PHPGenerator) generateAPIClientTestContent(_ *config.InternalModel) str
 // generateReadme creates a README.md file for the generated package.
 func (g *PHPGenerator) generateReadme(_ *config.InternalModel) error {
 	// Prepare template context
-	templateData := struct {
-		PackageName    string
-		Namespace      string
-		SpecFilename   string
-		GenerateClient bool
-	}{
+	templateData := templates.ReadmeData{
 		PackageName:    g.generatePackageName(),
 		Namespace:      g.config.Namespace,
 		SpecFilename:   filepath.Base(g.config.InputFile),
@@ -372,5 +486,6 @@ func (g *PHPGenerator) generateReadme(_ *config.InternalModel) error {
 		return fmt.Errorf("failed to execute README template: %w", err)
 	}
 
-	return os.WriteFile(filepath.Join(g.config.OutputDir, "README.md"), []byte(content.String()), 0644)
+	g.addFile("README.md", []byte(content.String()))
+	return nil
 }
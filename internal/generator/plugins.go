@@ -0,0 +1,52 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/floriscornel/piak/internal/plugin"
+)
+
+// generatePluginFiles runs every configured plugin and queues the files it returns,
+// so they are written exactly like the built-in files. A plugin cannot return the
+// manifest, which generation writes itself.
+func (g *PHPGenerator) generatePluginFiles(model *config.InternalModel) error {
+	for _, spec := range g.config.Plugins {
+		p, err := plugin.Parse(spec)
+		if err != nil {
+			return err
+		}
+
+		response, err := p.Run(model)
+		if err != nil {
+			return err
+		}
+
+		// Errors have failed the plugin already
+		for _, diagnostic := range response.Diagnostics {
+			message := fmt.Sprintf("plugin %s: %s", p.Name, diagnostic.Message)
+			switch diagnostic.Severity {
+			case plugin.SeverityWarning:
+				g.warnings = append(g.warnings, message)
+			case plugin.SeverityInfo:
+				g.notes = append(g.notes, message)
+			}
+		}
+
+		for _, file := range response.Files {
+			filePath := filepath.FromSlash(path.Clean(file.Path))
+			if strings.EqualFold(filePath, ManifestFile) {
+				return fmt.Errorf("plugin %s: file %s is the manifest of the generated files", p.Name, file.Path)
+			}
+			if g.hasFile(filePath) {
+				return fmt.Errorf("plugin %s: file %s conflicts with another generated file", p.Name, file.Path)
+			}
+			g.addFile(filePath, []byte(file.Content))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/floriscornel/piak/internal/analyzer"
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// convertRequestBody converts the request body of an operation, choosing the media
+// type the client sends. Bodies offering none the client supports are reported and
+// sent as JSON.
+func (c *modelConverter) convertRequestBody(op *analyzer.OperationInfo) *config.RequestBodyModel {
+	if op.RequestBody == nil {
+		return nil
+	}
+
+	model := &config.RequestBodyModel{Required: op.RequestBody.Required, MediaType: config.MediaTypeJSON}
+	var chosen *analyzer.MediaTypeInfo
+	best := 0
+	for _, content := range op.RequestBody.Content {
+		model.MediaTypes = append(model.MediaTypes, content.MediaType)
+		if rank := mediaTypeRank(content.MediaType); rank > best {
+			chosen, best = content, rank
+		}
+	}
+	if chosen == nil {
+		if len(model.MediaTypes) > 0 {
+			c.warnings = append(c.warnings, fmt.Sprintf(
+				"operation %q has a request body of unsupported media types %s, sending JSON",
+				op.OperationID, strings.Join(model.MediaTypes, ", ")))
+		}
+		return model
+	}
+
+	model.MediaType = chosen.MediaType
+	if chosen.MediaType == mediaTypeAny {
+		model.MediaType = config.MediaTypeOctetStream
+	}
+	if chosen.MediaType == config.MediaTypeMultipart {
+		model.Files = fileProperties(chosen.Schema)
+		model.PartContentTypes = chosen.Encoding
+	}
+	return model
+}
+
+// mediaTypeAny is the media range of request bodies of any media type, which the
+// client sends as octet-stream.
+const mediaTypeAny = "*/*"
+
+// mediaTypeRank returns how much the client prefers sending a body as a media type:
+// JSON, then multipart, then form-urlencoded, then raw octet-stream bodies, 0 for
+// media types it cannot send.
+func mediaTypeRank(mediaType string) int {
+	switch {
+	case mediaType == config.MediaTypeJSON:
+		return 5
+	case isJSONMediaType(mediaType):
+		return 4
+	case mediaType == config.MediaTypeMultipart:
+		return 3
+	case mediaType == config.MediaTypeForm:
+		return 2
+	case mediaType == config.MediaTypeOctetStream || mediaType == mediaTypeAny:
+		return 1
+	}
+	return 0
+}
+
+// fileProperties returns the properties of a multipart body schema holding files,
+// binary strings or lists of them, sorted by name.
+func fileProperties(schema *openapi3.SchemaRef) []string {
+	if schema == nil || schema.Value == nil {
+		return nil
+	}
+
+	var files []string
+	for name, property := range schema.Value.Properties {
+		if property == nil || property.Value == nil {
+			continue
+		}
+		value := property.Value
+		if value.Type.Is("array") && value.Items != nil && value.Items.Value != nil {
+			value = value.Items.Value
+		}
+		if value.Type.Is("string") && value.Format == "binary" {
+			files = append(files, name)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
@@ -0,0 +1,88 @@
+package generator
+
+import (
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/floriscornel/piak/internal/analyzer"
+	"github.com/floriscornel/piak/internal/config"
+)
+
+// ndjsonMediaTypes are the media types of newline-delimited JSON.
+var ndjsonMediaTypes = map[string]bool{
+	"application/x-ndjson":     true,
+	"application/ndjson":       true,
+	"application/jsonl":        true,
+	"application/x-jsonlines":  true,
+	"application/jsonlines":    true,
+	"application/json-lines":   true,
+	"application/x-json-lines": true,
+}
+
+// eventStreamMediaType is the media type of server-sent events.
+const eventStreamMediaType = "text/event-stream"
+
+// convertResponse converts the successful response of an operation, telling how the
+// client decodes it: as JSON when it has a JSON media type, as server-sent events, as
+// NDJSON values, or else as a binary stream. Operations declaring no content get nil
+// and are decoded as JSON.
+func (c *modelConverter) convertResponse(op *analyzer.OperationInfo) *config.ResponseModel {
+	if len(op.ResponseContent) == 0 {
+		return nil
+	}
+
+	model := &config.ResponseModel{Kind: config.ResponseBinary}
+	var events, ndjson *analyzer.MediaTypeInfo
+	for _, content := range op.ResponseContent {
+		model.MediaTypes = append(model.MediaTypes, content.MediaType)
+		switch {
+		case isJSONMediaType(content.MediaType):
+			model.Kind = config.ResponseJSON
+		case content.MediaType == eventStreamMediaType:
+			events = content
+		case ndjsonMediaTypes[content.MediaType] && ndjson == nil:
+			ndjson = content
+		}
+	}
+
+	switch {
+	case model.Kind == config.ResponseJSON:
+		return model
+	case events != nil:
+		// The schema describes the data of an event, which is text unless it is
+		// described as anything else
+		model.Kind = config.ResponseEvents
+		model.MediaTypes = []string{eventStreamMediaType}
+		schema := events.Schema
+		if schema != nil && schema.Value != nil && !schema.Value.Type.Is("string") {
+			model.JSONEvents = true
+			c.setItemClass(model, schema)
+		}
+	case ndjson != nil:
+		// NDJSON values are described by the schema of a value, or of a list of them
+		model.Kind = config.ResponseNDJSON
+		schema := ndjson.Schema
+		if schema != nil && schema.Ref == "" && schema.Value != nil && schema.Value.Type.Is("array") {
+			schema = schema.Value.Items
+		}
+		c.setItemClass(model, schema)
+	}
+	return model
+}
+
+// setItemClass sets the model the values of a response are hydrated into, if the
+// schema describing them references one.
+func (c *modelConverter) setItemClass(model *config.ResponseModel, schema *openapi3.SchemaRef) {
+	if schema != nil && schema.Ref != "" {
+		model.ItemClass = c.responseClass(analyzer.SchemaNameFromRef(schema.Ref))
+		model.ItemDataType = c.dataTypes[model.ItemClass]
+	}
+}
+
+// isJSONMediaType reports whether a media type is JSON: application/json or a +json
+// media type such as application/problem+json.
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == config.MediaTypeJSON ||
+		strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,93 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/floriscornel/piak/internal/analyzer"
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/floriscornel/piak/internal/naming"
+)
+
+// convertSecuritySchemes converts the supported security schemes. Each gets a client
+// method setting its credentials, set followed by the scheme name in PascalCase,
+// unique ignoring case among the client methods, including the operation methods
+// and iterators.
+func (c *modelConverter) convertSecuritySchemes(
+	schemes []*analyzer.SecuritySchemeInfo,
+	operations []*config.OperationModel,
+) []*config.SecuritySchemeModel {
+	taken := takenMethodNames(operations)
+	models := make([]*config.SecuritySchemeModel, 0, len(schemes))
+	for _, scheme := range schemes {
+		base := "set" + naming.Pascal(scheme.Name)
+		setterName := base
+		for n := 2; taken[strings.ToLower(setterName)]; n++ {
+			setterName = base + strconv.Itoa(n)
+		}
+		if setterName != base {
+			c.warnings = append(c.warnings, fmt.Sprintf(
+				"security scheme %q would have credential setter %s, which is already taken, generating %s instead",
+				scheme.Name, base, setterName))
+		}
+		taken[strings.ToLower(setterName)] = true
+
+		models = append(models, &config.SecuritySchemeModel{
+			Name:        scheme.Name,
+			Kind:        scheme.Kind,
+			In:          scheme.In,
+			ParamName:   scheme.ParamName,
+			TokenURL:    scheme.TokenURL,
+			Scopes:      scheme.Scopes,
+			Description: scheme.Description,
+			SetterName:  setterName,
+		})
+	}
+	return models
+}
+
+// takenMethodNames returns the lowercased names of the client methods: those of the
+// client itself and the operation methods and iterators.
+func takenMethodNames(operations []*config.OperationModel) map[string]bool {
+	taken := make(map[string]bool)
+	for _, name := range clientMethodNames {
+		taken[strings.ToLower(name)] = true
+	}
+	for _, op := range operations {
+		taken[strings.ToLower(op.MethodName)] = true
+		if op.Pagination != nil {
+			taken[strings.ToLower(op.Pagination.IteratorName)] = true
+		}
+	}
+	return taken
+}
+
+// convertSecurity converts the security requirements of an operation. Requirements
+// using a scheme the client does not support cannot be met and are left out; when
+// that leaves none, the operation is sent without authentication.
+func (c *modelConverter) convertSecurity(op *analyzer.OperationInfo) []map[string][]string {
+	var security []map[string][]string
+	for _, requirement := range op.Security {
+		converted := make(map[string][]string, len(requirement))
+		for name, scopes := range requirement {
+			if !c.securitySchemes[name] {
+				converted = nil
+				break
+			}
+			converted[name] = append([]string{}, scopes...)
+			sort.Strings(converted[name])
+		}
+		if converted != nil {
+			security = append(security, converted)
+		}
+	}
+
+	if len(security) == 0 && len(op.Security) > 0 {
+		c.warnings = append(c.warnings, fmt.Sprintf(
+			"operation %q only accepts unsupported security schemes, sending it without authentication",
+			op.OperationID))
+	}
+	return security
+}
@@ -0,0 +1,81 @@
+package generator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/floriscornel/piak/internal/analyzer"
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/floriscornel/piak/internal/naming"
+)
+
+// serverConstructorParams are the arguments of the named constructors of the client
+// besides the server variables, which must not take their names.
+var serverConstructorParams = map[string]bool{
+	"defaultHeaders": true, "httpClient": true, "requestFactory": true, "streamFactory": true, "retryPolicy": true,
+}
+
+// convertServers converts the servers of the spec. Each gets a named constructor of
+// the client, named after the server, unique ignoring case among the client methods,
+// including the operation methods, iterators and credential setters.
+func (c *modelConverter) convertServers(
+	servers []*analyzer.ServerInfo,
+	operations []*config.OperationModel,
+	schemes []*config.SecuritySchemeModel,
+) []*config.ServerModel {
+	taken := takenMethodNames(operations)
+	for _, scheme := range schemes {
+		taken[strings.ToLower(scheme.SetterName)] = true
+	}
+
+	models := convertServerList(servers)
+	for _, model := range models {
+		base := model.Name
+		constructorName := base
+		for n := 2; taken[strings.ToLower(constructorName)]; n++ {
+			constructorName = base + strconv.Itoa(n)
+		}
+		if constructorName != base {
+			c.warnings = append(c.warnings, fmt.Sprintf(
+				"server %q would have constructor %s, which is already taken, generating %s instead",
+				model.URL, base, constructorName))
+		}
+		taken[strings.ToLower(constructorName)] = true
+		model.ConstructorName = constructorName
+	}
+	return models
+}
+
+// convertServerList converts a list of servers, naming the constructor arguments
+// taking their variables.
+func convertServerList(servers []*analyzer.ServerInfo) []*config.ServerModel {
+	if len(servers) == 0 {
+		return nil
+	}
+	models := make([]*config.ServerModel, 0, len(servers))
+	for _, server := range servers {
+		wireNames := make([]string, 0, len(server.Variables))
+		for _, variable := range server.Variables {
+			wireNames = append(wireNames, variable.Name)
+		}
+		paramNames := naming.PropertyNames(wireNames, naming.CamelCase)
+
+		model := &config.ServerModel{Name: server.Name, URL: server.URL, Description: server.Description}
+		for _, variable := range server.Variables {
+			paramName := paramNames[variable.Name]
+			if serverConstructorParams[paramName] {
+				paramName = "server" + naming.Pascal(paramName)
+			}
+			model.Variables = append(model.Variables, &config.ServerVariableModel{
+				Name:        variable.Name,
+				Default:     variable.Default,
+				Enum:        variable.Enum,
+				Description: variable.Description,
+				ParamName:   paramName,
+			})
+		}
+		models = append(models, model)
+	}
+	return models
+}
@@ -0,0 +1,197 @@
+package generator
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/floriscornel/piak/internal/analyzer"
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/floriscornel/piak/internal/templates"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// mixedDataType is the PHPDoc type of a JSON object without a more precise type.
+const mixedDataType = "array<string, mixed>"
+
+// bigDecimalClass is the class holding decimals with config.DecimalBigDecimal.
+const bigDecimalClass = `\Brick\Math\BigDecimal`
+
+// phpTypeExtension marks a number or string schema as a decimal amount when set to
+// "money", like format decimal does.
+const phpTypeExtension = "x-php-type"
+
+// phpType resolves the PHP type of a schema. Array items and additionalProperties
+// are resolved recursively, so doc types such as list<Pet> and
+// array<string, list<int>> are exact at any depth.
+func (c *modelConverter) phpType(schemaRef *openapi3.SchemaRef) config.PHPType {
+	if schemaRef == nil || schemaRef.Value == nil {
+		return config.PHPType{Name: "mixed", DocComment: "mixed", DataType: "mixed"}
+	}
+	schema := schemaRef.Value
+
+	if schemaRef.Ref != "" {
+		className := c.refClassName(schemaRef.Ref)
+		phpType := config.PHPType{
+			Name:       className,
+			IsNullable: isNullable(schema),
+			DocComment: className,
+			DataType:   mixedDataType,
+		}
+		if cases := c.enums[analyzer.SchemaNameFromRef(schemaRef.Ref)]; len(cases) > 0 {
+			phpType.IsEnum = true
+			phpType.DataType = templates.EnumBackingType(cases)
+		} else if alias, ok := c.dataTypes[className]; ok && !c.onCycle(analyzer.SchemaNameFromRef(schemaRef.Ref)) {
+			// PHPStan rejects circular type aliases, so references back into the
+			// cycle of the current schema keep the generic data type
+			phpType.DataType = alias
+		}
+		return phpType
+	}
+
+	name := mapOpenAPITypeToPHP(schema)
+	phpType := config.PHPType{
+		Name:       name,
+		IsNullable: isNullable(schema),
+		DocComment: name,
+		DataType:   name,
+	}
+
+	switch {
+	case name == arrayType && schema.Type.Includes(arrayType):
+		items := c.phpType(schema.Items)
+		phpType.IsArray = true
+		phpType.Items = &items
+		phpType.DocComment = "list<" + nullableDoc(items.DocComment, items.IsNullable) + ">"
+		phpType.DataType = "list<" + nullableDoc(items.DataType, items.IsNullable) + ">"
+	case name == arrayType:
+		// Objects are arrays with string keys
+		phpType.DocComment, phpType.DataType = mixedDataType, mixedDataType
+		if additional := schema.AdditionalProperties.Schema; additional != nil {
+			items := c.phpType(additional)
+			phpType.IsArray = true
+			phpType.IsMap = true
+			phpType.Items = &items
+			phpType.DocComment = "array<string, " + nullableDoc(items.DocComment, items.IsNullable) + ">"
+			phpType.DataType = "array<string, " + nullableDoc(items.DataType, items.IsNullable) + ">"
+		}
+	default:
+		c.applyNumeric(schema, &phpType)
+	}
+
+	return phpType
+}
+
+// applyNumeric holds int64 integers and decimals as configured: int64 integers as
+// numeric strings, which are exact on 32-bit builds too, and decimals as numeric
+// strings or BigDecimal objects. Their data also accepts the ints and floats that
+// json_decode produces.
+func (c *modelConverter) applyNumeric(schema *openapi3.Schema, phpType *config.PHPType) {
+	decimalData := "int|float|string"
+	if phpType.Name == "string" {
+		decimalData = "string"
+	}
+
+	switch {
+	case phpType.Name == "int" && schema.Format == "int64" && c.int64 == config.Int64String:
+		phpType.Name, phpType.DocComment, phpType.DataType = "string", "string", "int|string"
+		phpType.Numeric = config.NumericIntString
+	case !isDecimal(schema):
+	case c.decimal == config.DecimalString:
+		phpType.Name, phpType.DocComment, phpType.DataType = "string", "string", decimalData
+		phpType.Numeric = config.NumericDecimalString
+	case c.decimal == config.DecimalBigDecimal:
+		phpType.Name, phpType.DocComment, phpType.DataType = bigDecimalClass, bigDecimalClass, decimalData
+		phpType.Numeric = config.NumericBigDecimal
+	}
+}
+
+// isDecimal reports whether a number or string schema holds a decimal: it has
+// format decimal or is marked as money.
+func isDecimal(schema *openapi3.Schema) bool {
+	if !schema.Type.Includes("number") && !schema.Type.Includes("string") {
+		return false
+	}
+	marker, _ := schema.Extensions[phpTypeExtension].(string)
+	return schema.Format == "decimal" || marker == "money"
+}
+
+// numericString formats a number from the spec, such as a default, as the numeric
+// string a property holding numbers as strings takes.
+func numericString(value interface{}) interface{} {
+	switch number := value.(type) {
+	case int:
+		return strconv.Itoa(number)
+	case json.Number:
+		return string(number)
+	case float64:
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	return value
+}
+
+// onCycle reports whether a schema is on a reference cycle through the schema being
+// converted.
+func (c *modelConverter) onCycle(name string) bool {
+	for _, member := range c.cycles[c.current] {
+		if member == name {
+			return true
+		}
+	}
+	return false
+}
+
+// nullableDoc adds null to a doc type when the value may be null.
+func nullableDoc(docType string, nullable bool) string {
+	if !nullable || docType == "mixed" {
+		return docType
+	}
+	return docType + "|null"
+}
+
+// dataTypeAliases assigns every model class the name of the PHPStan type alias
+// describing its array data, such as PetData, unique ignoring case among the
+// aliases, the model classes and the other classes, so importing it never shadows
+// a class.
+func dataTypeAliases(classNames, otherClassNames []string) map[string]string {
+	taken := make(map[string]bool, 2*len(classNames)+len(otherClassNames))
+	for _, className := range append(append([]string(nil), classNames...), otherClassNames...) {
+		taken[strings.ToLower(className)] = true
+	}
+
+	aliases := make(map[string]string, len(classNames))
+	for _, className := range classNames {
+		base := className + "Data"
+		alias := base
+		for n := 2; taken[strings.ToLower(alias)]; n++ {
+			alias = base + strconv.Itoa(n)
+		}
+		taken[strings.ToLower(alias)] = true
+		aliases[className] = alias
+	}
+	return aliases
+}
+
+// linkModels points every class and enum type in the properties of the models,
+// including array items, to the model of that class or enum.
+func linkModels(models map[string]*config.SchemaModel) {
+	byClass := make(map[string]*config.SchemaModel, len(models))
+	for _, model := range models {
+		byClass[model.Name] = model
+	}
+
+	var link func(phpType *config.PHPType)
+	link = func(phpType *config.PHPType) {
+		if phpType.Items != nil {
+			link(phpType.Items)
+		}
+		if model, ok := byClass[phpType.Name]; ok {
+			phpType.Model = model
+		}
+	}
+	for _, model := range models {
+		for _, prop := range model.Properties {
+			link(&prop.PHPType)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package naming
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/floriscornel/piak/internal/php"
+)
+
+// Style is a naming convention for generated PHP identifiers.
+type Style string
+
+// Supported property naming styles.
+const (
+	CamelCase Style = "camel"
+	SnakeCase Style = "snake"
+)
+
+// fallbackName is used when a wire name contains no letters or digits at all.
+const fallbackName = "value"
+
+// ParseStyle parses a naming style, defaulting to camelCase when empty.
+func ParseStyle(s string) (Style, error) {
+	switch Style(s) {
+	case "", CamelCase:
+		return CamelCase, nil
+	case SnakeCase:
+		return SnakeCase, nil
+	default:
+		return "", fmt.Errorf("unknown naming style %q, expected %q or %q", s, CamelCase, SnakeCase)
+	}
+}
+
+// Words splits a name into words at non-alphanumeric characters and case changes,
+// keeping acronyms together: "HTTPStatus-code" becomes [HTTP Status code].
+func Words(s string) []string {
+	var words []string
+	var current []rune
+
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, string(current))
+			current = nil
+		}
+	}
+
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) && len(current) > 0 {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			// fooBar -> foo|Bar, HTTPCode -> HTTP|Code
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+
+	return words
+}
+
+// Camel converts a name to camelCase.
+func Camel(s string) string {
+	words := Words(s)
+	for i, word := range words {
+		if i == 0 {
+			words[i] = strings.ToLower(word)
+		} else {
+			words[i] = capitalize(word)
+		}
+	}
+	return identifier(strings.Join(words, ""))
+}
+
+// Snake converts a name to snake_case.
+func Snake(s string) string {
+	words := Words(s)
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	return identifier(strings.Join(words, "_"))
+}
+
+// Pascal converts a name to PascalCase.
+func Pascal(s string) string {
+	words := Words(s)
+	for i, word := range words {
+		words[i] = capitalize(word)
+	}
+	return identifier(strings.Join(words, ""))
+}
+
+// Apply converts a name to the given style.
+func (s Style) Apply(name string) string {
+	if s == SnakeCase {
+		return Snake(name)
+	}
+	return Camel(name)
+}
+
+// PropertyNames maps wire names to valid, unique PHP property names in the given
+// style. Names are assigned in the order given, so callers should pass a stable
+// order; a later name colliding with an earlier one gets a numeric suffix.
+func PropertyNames(wireNames []string, style Style) map[string]string {
+	names := make(map[string]string, len(wireNames))
+	taken := make(map[string]bool, len(wireNames))
+
+	for _, wire := range wireNames {
+		name := style.Apply(wire)
+		// $this cannot be redeclared; all other reserved words are valid property names
+		if name == "this" {
+			name += "Value"
+			if style == SnakeCase {
+				name = "this_value"
+			}
+		}
+
+		names[wire] = unique(name, taken)
+	}
+
+	return names
+}
+
+// EnumCaseNames maps enum values, given as strings, to unique PascalCase case
+// names. Names that would not start with a letter get a "Value" prefix, empty
+// values without letters or digits are called "Empty" and "class", which cannot
+// name a case, becomes "ClassValue".
+func EnumCaseNames(values []string) []string {
+	names := make([]string, 0, len(values))
+	taken := make(map[string]bool, len(values))
+
+	for _, value := range values {
+		name := strings.TrimLeft(Pascal(value), "_")
+		switch {
+		case len(Words(value)) == 0:
+			name = "Empty"
+		case !unicode.IsLetter([]rune(name)[0]):
+			name = "Value" + name
+		case strings.EqualFold(name, "class"):
+			name += "Value"
+		}
+
+		names = append(names, unique(name, taken))
+	}
+
+	return names
+}
+
+// unique returns name, or name with the lowest numeric suffix that is not taken,
+// and marks the result as taken.
+func unique(name string, taken map[string]bool) string {
+	candidate := name
+	for n := 2; taken[candidate]; n++ {
+		candidate = name + strconv.Itoa(n)
+	}
+	taken[candidate] = true
+	return candidate
+}
+
+// capitalize upper-cases the first letter of a word and lower-cases the rest.
+func capitalize(word string) string {
+	runes := []rune(strings.ToLower(word))
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// identifier makes a converted name a valid PHP identifier.
+func identifier(name string) string {
+	if name == "" {
+		return fallbackName
+	}
+	return php.Identifier(name)
+}
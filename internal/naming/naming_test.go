@@ -0,0 +1,71 @@
+package naming_test
+
+import (
+	"testing"
+
+	"github.com/floriscornel/piak/internal/naming"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStyle(t *testing.T) {
+	style, err := naming.ParseStyle("")
+	require.NoError(t, err)
+	assert.Equal(t, naming.CamelCase, style)
+
+	style, err = naming.ParseStyle("snake")
+	require.NoError(t, err)
+	assert.Equal(t, naming.SnakeCase, style)
+
+	_, err = naming.ParseStyle("kebab")
+	assert.Error(t, err)
+}
+
+func TestWords(t *testing.T) {
+	assert.Equal(t, []string{"HTTP", "Status", "code"}, naming.Words("HTTPStatus-code"))
+	assert.Equal(t, []string{"first", "Name"}, naming.Words("firstName"))
+	assert.Equal(t, []string{"type"}, naming.Words("@type"))
+	assert.Empty(t, naming.Words("$$$"))
+}
+
+func TestCamelAndSnake(t *testing.T) {
+	tests := []struct {
+		input string
+		camel string
+		snake string
+	}{
+		{"first-name", "firstName", "first_name"},
+		{"@type", "type", "type"},
+		{"2fa_enabled", "_2faEnabled", "_2fa_enabled"},
+		{"HTTPCode", "httpCode", "http_code"},
+		{"user.id", "userId", "user_id"},
+		{"class", "class", "class"},
+		{"$$$", "value", "value"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.camel, naming.Camel(tt.input), tt.input)
+		assert.Equal(t, tt.snake, naming.Snake(tt.input), tt.input)
+	}
+	assert.Equal(t, "UserProfile", naming.Pascal("user_profile"))
+}
+
+func TestPropertyNames(t *testing.T) {
+	names := naming.PropertyNames([]string{"first-name", "first_name", "firstName", "this", "list"}, naming.CamelCase)
+	assert.Equal(t, map[string]string{
+		"first-name": "firstName",
+		"first_name": "firstName2",
+		"firstName":  "firstName3",
+		"this":       "thisValue",
+		"list":       "list",
+	}, names)
+
+	names = naming.PropertyNames([]string{"userId", "this"}, naming.SnakeCase)
+	assert.Equal(t, "user_id", names["userId"])
+	assert.Equal(t, "this_value", names["this"])
+}
+
+func TestEnumCaseNames(t *testing.T) {
+	assert.Equal(t,
+		[]string{"Available", "InStock", "Value1st", "Value2", "ClassValue", "Empty", "Available2"},
+		naming.EnumCaseNames([]string{"available", "in-stock", "1st", "2", "class", "", "AVAILABLE"}))
+}
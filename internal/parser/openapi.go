@@ -2,23 +2,38 @@ package parser
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v3"
 )
 
 // OpenAPIParser handles parsing of OpenAPI specifications.
 type OpenAPIParser struct {
 	validateSpec bool
 	resolveRefs  bool
+	sources      map[string]bool
+	integers     map[float64]string // float value -> literal text, for integers floats cannot hold
 }
 
+// integerLiteral matches the decimal integers of a document.
+var integerLiteral = regexp.MustCompile(`^[-+]?[0-9]+$`)
+
 // New creates a new OpenAPIParser instance.
 func New(validateSpec, resolveRefs bool) *OpenAPIParser {
 	return &OpenAPIParser{
 		validateSpec: validateSpec,
 		resolveRefs:  resolveRefs,
+		sources:      make(map[string]bool),
+		integers:     make(map[float64]string),
 	}
 }
 
@@ -29,8 +44,14 @@ func (p *OpenAPIParser) ParseFile(filePath string) (*openapi3.T, error) {
 		return nil, fmt.Errorf("input file does not exist: %s", filePath)
 	}
 
+	// Track every local file the loader reads so callers can watch them
+	p.sources = map[string]bool{p.absPath(filePath): true}
+	p.integers = make(map[float64]string)
+
 	// Load the OpenAPI specification
 	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = p.resolveRefs
+	loader.ReadFromURIFunc = p.recordingReader
 	spec, err := loader.LoadFromFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load OpenAPI specification: %w", err)
@@ -46,3 +67,89 @@ func (p *OpenAPIParser) ParseFile(filePath string) (*openapi3.T, error) {
 
 	return spec, nil
 }
+
+// SourceFiles returns the absolute paths of the input file and every local file
+// it references through $ref, as read by the last call to ParseFile.
+func (p *OpenAPIParser) SourceFiles() []string {
+	files := make([]string, 0, len(p.sources))
+	for file := range p.sources {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// IntegerText returns the text of the integer literal of the documents read by the
+// last call to ParseFile that decodes to f, for integers from 2^53 on, which the
+// loader decodes to the nearest float. It reports false when the documents hold no
+// such literal, or several that decode to f.
+func (p *OpenAPIParser) IntegerText(f float64) (string, bool) {
+	text := p.integers[f]
+	return text, text != ""
+}
+
+// recordingReader reads local files and records their paths; other URIs are
+// delegated to the default loader. The large integers of every document read are
+// recorded for IntegerText.
+func (p *OpenAPIParser) recordingReader(loader *openapi3.Loader, location *url.URL) ([]byte, error) {
+	data, err := openapi3.ReadFromFile(loader, location)
+	if errors.Is(err, openapi3.ErrURINotSupported) {
+		data, err = openapi3.DefaultReadFromURI(loader, location)
+	} else if err == nil {
+		p.sources[p.absPath(filepath.FromSlash(location.Path))] = true
+	}
+	if err == nil {
+		p.recordIntegers(data)
+	}
+	return data, err
+}
+
+// recordIntegers records the integer literals of a JSON or YAML document that a
+// float cannot hold exactly. Literals decoding to the same float as another are
+// recorded as ambiguous. Documents that do not parse are left to the loader to
+// report.
+func (p *OpenAPIParser) recordIntegers(data []byte) {
+	var root yaml.Node
+	if yaml.Unmarshal(data, &root) != nil {
+		return
+	}
+
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		// Integers beyond the range of an int64 resolve to floats
+		if node.Kind == yaml.ScalarNode && (node.Tag == "!!int" || node.Tag == "!!float") &&
+			integerLiteral.MatchString(node.Value) {
+			text := canonicalInteger(node.Value)
+			f, err := strconv.ParseFloat(text, 64)
+			if err == nil && (f >= 1<<53 || f <= -(1<<53)) {
+				if recorded, ok := p.integers[f]; ok && recorded != text {
+					text = ""
+				}
+				p.integers[f] = text
+			}
+		}
+		for _, child := range node.Content {
+			walk(child)
+		}
+	}
+	walk(&root)
+}
+
+// canonicalInteger returns an integer literal without a plus sign or leading zeros,
+// which PHP would read as octal.
+func canonicalInteger(literal string) string {
+	sign, digits := "", strings.TrimPrefix(literal, "+")
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	return sign + strings.TrimLeft(digits, "0")
+}
+
+// absPath returns an absolute version of path, or path itself if that fails.
+func (p *OpenAPIParser) absPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return abs
+}
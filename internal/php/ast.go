@@ -0,0 +1,118 @@
+package php
+
+// Visibility is a PHP member visibility modifier.
+type Visibility string
+
+// Visibility modifiers.
+const (
+	Public    Visibility = "public"
+	Protected Visibility = "protected"
+	Private   Visibility = "private"
+)
+
+// File is a PHP source file declaring a single class or enum.
+type File struct {
+	Namespace string
+	Imports   *Imports
+	Decl      Decl
+}
+
+// Decl is a top-level declaration: a *Class or an *Enum.
+type Decl interface {
+	declName() string
+}
+
+// Class is a PHP class declaration.
+type Class struct {
+	Name       string
+	Doc        *DocBlock
+	Attributes []string
+	Final      bool
+	Abstract   bool
+	Readonly   bool
+	Extends    string
+	Implements []string
+	Constants  []*Constant
+	Properties []*Property
+	Methods    []*Method
+}
+
+// Enum is a PHP enum declaration.
+type Enum struct {
+	Name        string
+	Doc         *DocBlock
+	Attributes  []string
+	BackingType string // "string", "int" or empty for a pure enum
+	Implements  []string
+	Cases       []*EnumCase
+	Constants   []*Constant
+	Methods     []*Method
+}
+
+// EnumCase is a single case of an enum. Value is a PHP literal and empty for pure enums.
+type EnumCase struct {
+	Name       string
+	Value      string
+	Doc        *DocBlock
+	Attributes []string
+}
+
+// Constant is a class or enum constant. Value is a PHP constant expression.
+type Constant struct {
+	Name       string
+	Value      string
+	Type       string
+	Visibility Visibility
+	Doc        *DocBlock
+}
+
+// Property is a class property. Default is a PHP constant expression, empty for none.
+type Property struct {
+	Name       string
+	Type       string
+	Default    string
+	Visibility Visibility
+	Static     bool
+	Readonly   bool
+	Doc        *DocBlock
+}
+
+// Method is a class or enum method. Abstract methods are printed without a body.
+type Method struct {
+	Name       string
+	Doc        *DocBlock
+	Attributes []string
+	Visibility Visibility
+	Static     bool
+	Final      bool
+	Abstract   bool
+	Params     []*Param
+	ReturnType string
+	Body       []Stmt
+}
+
+// Param is a function parameter. Setting Promote turns it into a promoted
+// constructor property with that visibility, which Doc documents.
+type Param struct {
+	Name       string
+	Type       string
+	Default    string
+	Promote    Visibility
+	Readonly   bool
+	Variadic   bool
+	Attributes []string
+	Doc        *DocBlock
+}
+
+func (c *Class) declName() string { return c.Name }
+func (e *Enum) declName() string  { return e.Name }
+
+// Method returns the class method with the given name, or nil.
+func (c *Class) Method(name string) *Method {
+	for _, method := range c.Methods {
+		if method.Name == name {
+			return method
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package php
+
+import "strings"
+
+// DocBlock is a PHPDoc comment: free-form description lines followed by tags.
+type DocBlock struct {
+	Lines []string
+	Tags  []string
+}
+
+// NewDocBlock creates a docblock with the given description lines. Lines containing
+// newlines are split, so multi-line descriptions can be passed as a single string.
+func NewDocBlock(lines ...string) *DocBlock {
+	doc := &DocBlock{}
+	for _, line := range lines {
+		doc.Lines = append(doc.Lines, strings.Split(line, "\n")...)
+	}
+	return doc
+}
+
+// Tag appends a tag such as Tag("param", "int $id").
+func (d *DocBlock) Tag(name, value string) *DocBlock {
+	tag := "@" + name
+	if value != "" {
+		tag += " " + value
+	}
+	d.Tags = append(d.Tags, tag)
+	return d
+}
+
+// IsEmpty reports whether the docblock has neither description nor tags.
+func (d *DocBlock) IsEmpty() bool {
+	return d == nil || (len(d.Lines) == 0 && len(d.Tags) == 0)
+}
+
+// print renders the docblock, separating the description from the tags with an empty
+// line. All text is escaped with DocText, so spec-derived content can be used as is.
+func (d *DocBlock) print(p *printer) {
+	if d.IsEmpty() {
+		return
+	}
+
+	var lines []string
+	for _, line := range d.Lines {
+		lines = append(lines, strings.Split(DocText(line), "\n")...)
+	}
+	lines = trimBlankEdges(lines)
+
+	p.line("/**")
+	for _, line := range lines {
+		p.docLine(line)
+	}
+	if len(lines) > 0 && len(d.Tags) > 0 {
+		p.docLine("")
+	}
+	for _, tag := range d.Tags {
+		// Only the tag's value is escaped; the tag name itself must stay a tag
+		name, value, _ := strings.Cut(tag, " ")
+		for i, line := range strings.Split(DocText(value), "\n") {
+			if i == 0 {
+				line = strings.TrimRight(name+" "+line, " ")
+			}
+			p.docLine(line)
+		}
+	}
+	p.line(" */")
+}
+
+// trimBlankEdges removes leading and trailing blank lines.
+func trimBlankEdges(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
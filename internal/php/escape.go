@@ -0,0 +1,122 @@
+package php
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Escapers for text taken from a specification. Every value interpolated into
+// generated code must go through the escaper for its context, so a hostile or
+// sloppy spec cannot break out of a string, a comment or an identifier.
+
+// StringLiteral returns s as a single-quoted PHP string literal.
+func StringLiteral(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// DocText makes s safe inside a docblock. Line endings are normalized to \n, the
+// comment terminator is broken up and lines starting with @ are escaped so they
+// cannot be read as tags. The result may span several lines.
+func DocText(s string) string {
+	s = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(s)
+	s = strings.ReplaceAll(s, "*/", `*\/`)
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		if strings.HasPrefix(trimmed, "@") {
+			lines[i] = line[:len(line)-len(trimmed)] + `\` + trimmed
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// DocComment escapes s with DocText and prefixes continuation lines with the
+// docblock decoration, for use after " * " in a top-level docblock. Trailing
+// blank lines are dropped.
+func DocComment(s string) string {
+	lines := strings.Split(strings.TrimRight(DocText(s), "\n\t "), "\n")
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			lines[i] = " *"
+		} else {
+			lines[i] = " * " + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+var (
+	markdownFence    = regexp.MustCompile("^\\s*(```|~~~)")
+	markdownHeading  = regexp.MustCompile(`^\s{0,3}#{1,6}\s+`)
+	markdownLink     = regexp.MustCompile(`!?\[([^\]]*)\]\(([^()\s]+)(?:\s+"[^"]*")?\)`)
+	markdownStrong   = regexp.MustCompile(`(\*\*|__)([^*_]+)(\*\*|__)`)
+	markdownAutolink = regexp.MustCompile(`<([A-Za-z][A-Za-z0-9+.-]*:[^<>\s]*)>`)
+	markdownLineHTML = regexp.MustCompile(`(?i)<br\s*/?>`)
+	markdownHTML     = regexp.MustCompile(`</?[A-Za-z][^<>]*>`)
+)
+
+// MarkdownText turns a CommonMark description from a specification into plain text
+// for a docblock: code fences, heading markers, strong emphasis, autolink brackets
+// and HTML tags are dropped and links become "text (url)". The result still needs DocText or
+// DocComment, which MarkdownText does not apply.
+func MarkdownText(s string) string {
+	s = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(s)
+	s = markdownLineHTML.ReplaceAllString(s, "\n")
+
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if markdownFence.MatchString(line) {
+			continue
+		}
+		line = markdownHeading.ReplaceAllString(line, "")
+		line = markdownLink.ReplaceAllStringFunc(line, func(link string) string {
+			match := markdownLink.FindStringSubmatch(link)
+			if match[1] == "" || match[1] == match[2] {
+				return match[2]
+			}
+			return match[1] + " (" + match[2] + ")"
+		})
+		line = markdownStrong.ReplaceAllString(line, "$2")
+		line = markdownAutolink.ReplaceAllString(line, "$1")
+		line = markdownHTML.ReplaceAllString(line, "")
+		// Trailing spaces are a hard line break, which is a line break already
+		lines = append(lines, strings.TrimRight(line, " \t"))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// Identifier turns s into a valid PHP identifier: characters outside
+// [A-Za-z0-9_] become underscores and a leading digit is prefixed with one.
+func Identifier(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+// JSONString returns s as a quoted JSON string, without HTML escaping.
+func JSONString(s string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	// Encoding a string cannot fail
+	_ = encoder.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
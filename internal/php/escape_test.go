@@ -0,0 +1,52 @@
+package php_test
+
+import (
+	"testing"
+
+	"github.com/floriscornel/piak/internal/php"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringLiteral(t *testing.T) {
+	assert.Equal(t, `'plain'`, php.StringLiteral("plain"))
+	assert.Equal(t, `'it\'s'`, php.StringLiteral("it's"))
+	assert.Equal(t, `'C:\\path\\'`, php.StringLiteral(`C:\path\`))
+	assert.Equal(t, `'\'); system(\'id'`, php.StringLiteral(`'); system('id`))
+}
+
+func TestDocText(t *testing.T) {
+	assert.Equal(t, `ends *\/ here`, php.DocText("ends */ here"))
+	assert.Equal(t, "first\n\\@return void\n  \\@param int $x", php.DocText("first\r\n@return void\r  @param int $x"))
+	assert.Equal(t, "mail me@example.com", php.DocText("mail me@example.com"))
+}
+
+func TestDocComment(t *testing.T) {
+	assert.Equal(t, "first\n *\n * third", php.DocComment("first\n\nthird\n\n"))
+}
+
+func TestMarkdownText(t *testing.T) {
+	markdown := "## Pets\r\n\nA **pet** in the [store](https://example.com/store \"Store\").<br>See <https://x.io>.\n\n" +
+		"```json\n{\"name\": \"Rex\"}\n```\n"
+	expected := "Pets\n\nA pet in the store (https://example.com/store).\nSee https://x.io.\n\n{\"name\": \"Rex\"}"
+	assert.Equal(t, expected, php.MarkdownText(markdown))
+	assert.Equal(t, "a < b and c > d", php.MarkdownText("a < b and c > d"))
+}
+
+func TestIdentifier(t *testing.T) {
+	tests := map[string]string{
+		"name":       "name",
+		"first-name": "first_name",
+		"@type":      "_type",
+		"2fa":        "_2fa",
+		"it's":       "it_s",
+		"":           "_",
+	}
+	for input, expected := range tests {
+		assert.Equal(t, expected, php.Identifier(input), input)
+	}
+}
+
+func TestJSONString(t *testing.T) {
+	assert.Equal(t, `"say \"hi\" <b>"`, php.JSONString(`say "hi" <b>`))
+	assert.Equal(t, `"App\\Api\\"`, php.JSONString(`App\Api\`))
+}
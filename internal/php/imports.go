@@ -0,0 +1,151 @@
+package php
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Imports tracks the use statements of a file. Class names are registered while
+// building the file and Name returns how to refer to them, importing them when
+// needed and aliasing imports whose short names would clash.
+//
+// PHP class names are case-insensitive, so all comparisons ignore case.
+type Imports struct {
+	namespace string
+	qualified bool
+	aliases   map[string]string // lower-case alias -> fully qualified name
+	names     map[string]string // lower-case fully qualified name -> alias
+	uses      []Use
+}
+
+// Use is a single use statement.
+type Use struct {
+	Name  string
+	Alias string
+}
+
+// NewImports creates an import tracker for a file in namespace. The declared names
+// (e.g. the class defined by the file) are reserved and never used as aliases.
+func NewImports(namespace string, declared ...string) *Imports {
+	imports := &Imports{
+		namespace: strings.Trim(namespace, `\`),
+		aliases:   make(map[string]string),
+		names:     make(map[string]string),
+	}
+	for _, name := range declared {
+		imports.reserve(name, imports.qualify(name))
+	}
+	return imports
+}
+
+// NewQualifiedImports creates an import tracker for a file whose namespace is not
+// known, which never imports: names relative to the file's namespace are kept as
+// they are and all other names are fully qualified.
+func NewQualifiedImports() *Imports {
+	imports := NewImports("")
+	imports.qualified = true
+	return imports
+}
+
+// Name returns the name to use in code for a class. Names with a leading backslash
+// are fully qualified; other names are relative to the file's namespace.
+func (i *Imports) Name(class string) string {
+	if i.qualified {
+		return class
+	}
+
+	fqn := strings.TrimPrefix(class, `\`)
+	if !strings.HasPrefix(class, `\`) {
+		fqn = i.qualify(class)
+	}
+
+	if alias, ok := i.names[strings.ToLower(fqn)]; ok {
+		return alias
+	}
+
+	short := shortName(fqn)
+
+	// Classes in the file's namespace need no import, as long as nothing else took their name
+	if namespaceOf(fqn) == i.namespace {
+		if _, taken := i.aliases[strings.ToLower(short)]; !taken {
+			i.reserve(short, fqn)
+			return short
+		}
+		return `\` + fqn
+	}
+
+	alias := i.freeAlias(fqn)
+	i.reserve(alias, fqn)
+	use := Use{Name: fqn}
+	if alias != short {
+		use.Alias = alias
+	}
+	i.uses = append(i.uses, use)
+	return alias
+}
+
+// Uses returns the use statements, sorted by name.
+func (i *Imports) Uses() []Use {
+	uses := append([]Use{}, i.uses...)
+	sort.Slice(uses, func(a, b int) bool {
+		return strings.ToLower(uses[a].Name) < strings.ToLower(uses[b].Name)
+	})
+	return uses
+}
+
+// String renders the use statement without the trailing semicolon.
+func (u Use) String() string {
+	if u.Alias != "" {
+		return "use " + u.Name + " as " + u.Alias
+	}
+	return "use " + u.Name
+}
+
+// freeAlias picks an alias for fqn: its short name if free, otherwise the short
+// name prefixed with parent namespace segments, e.g. MathBigDecimal.
+func (i *Imports) freeAlias(fqn string) string {
+	segments := strings.Split(fqn, `\`)
+	alias := segments[len(segments)-1]
+	for n := len(segments) - 2; n >= 0; n-- {
+		if _, taken := i.aliases[strings.ToLower(alias)]; !taken {
+			return alias
+		}
+		alias = segments[n] + alias
+	}
+
+	base := alias
+	for n := 2; ; n++ {
+		if _, taken := i.aliases[strings.ToLower(alias)]; !taken {
+			return alias
+		}
+		alias = base + strconv.Itoa(n)
+	}
+}
+
+// reserve records that alias refers to fqn.
+func (i *Imports) reserve(alias, fqn string) {
+	i.aliases[strings.ToLower(alias)] = fqn
+	i.names[strings.ToLower(fqn)] = alias
+}
+
+// qualify resolves a name relative to the file's namespace.
+func (i *Imports) qualify(name string) string {
+	if i.namespace == "" {
+		return name
+	}
+	return i.namespace + `\` + name
+}
+
+// shortName returns the last segment of a qualified name.
+func shortName(fqn string) string {
+	return fqn[strings.LastIndex(fqn, `\`)+1:]
+}
+
+// namespaceOf returns everything but the last segment of a qualified name.
+func namespaceOf(fqn string) string {
+	if idx := strings.LastIndex(fqn, `\`); idx >= 0 {
+		return fqn[:idx]
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package php
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Literal returns a decoded JSON or YAML value, such as a schema default, as a PHP
+// constant expression. Objects become arrays with their keys in sorted order.
+func Literal(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "null", nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case string:
+		return StringLiteral(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return floatLiteral(v)
+	case json.Number:
+		return integerLiteral(v)
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			literal, err := Literal(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, literal)
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		items := make([]string, 0, len(v))
+		for _, key := range keys {
+			literal, err := Literal(v[key])
+			if err != nil {
+				return "", err
+			}
+			items = append(items, StringLiteral(key)+" => "+literal)
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	default:
+		return "", fmt.Errorf("unsupported value of type %T", value)
+	}
+}
+
+// floatLiteral formats a number, without a fraction when it is a whole number
+// that fits in an int, since decoded JSON represents every number as a float.
+func floatLiteral(f float64) (string, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return "", fmt.Errorf("unsupported number: %v", f)
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return strconv.FormatInt(int64(f), 10), nil
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64), nil
+}
+
+// integerLiteral formats the exact text of an integer too large for a float to hold,
+// as long as it fits in an int.
+func integerLiteral(number json.Number) (string, error) {
+	if _, err := strconv.ParseInt(string(number), 10, 64); err != nil {
+		return "", fmt.Errorf("unsupported integer %s, it does not fit in an int", number)
+	}
+	return string(number), nil
+}
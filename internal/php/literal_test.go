@@ -0,0 +1,47 @@
+package php_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/floriscornel/piak/internal/php"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLiteral(t *testing.T) {
+	tests := []struct {
+		value    any
+		expected string
+	}{
+		{nil, "null"},
+		{true, "true"},
+		{"it's", `'it\'s'`},
+		{float64(10), "10"},
+		{0.5, "0.5"},
+		{float64(1<<53 - 1), "9007199254740991"},
+		{-float64(1<<53 - 1), "-9007199254740991"},
+		{float64(1 << 53), "9.007199254740992e+15"},
+		{-float64(1 << 53), "-9.007199254740992e+15"},
+		{-1.5, "-1.5"},
+		{1e21, "1e+21"},
+		{json.Number("9007199254740993"), "9007199254740993"},
+		{json.Number("-9223372036854775808"), "-9223372036854775808"},
+		{[]any{"a", float64(1)}, "['a', 1]"},
+		{map[string]any{}, "[]"},
+		{map[string]any{"b": false, "a": []any{}}, "['a' => [], 'b' => false]"},
+	}
+	for _, tt := range tests {
+		literal, err := php.Literal(tt.value)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, literal)
+	}
+
+	_, err := php.Literal(math.Inf(1))
+	assert.Error(t, err)
+	_, err = php.Literal(json.Number("9223372036854775808"))
+	assert.Error(t, err)
+	_, err = php.Literal(struct{}{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,382 @@
+package php
+
+import (
+	"strings"
+)
+
+// indentUnit is the PER-CS indentation: four spaces.
+const indentUnit = "    "
+
+// maxLineLength is the soft line length limit; longer signatures are split.
+const maxLineLength = 120
+
+// printer accumulates lines at the current indentation level.
+type printer struct {
+	lines []string
+	level int
+}
+
+// Print renders a complete PHP file following PER Coding Style 2.0.
+func Print(file *File) string {
+	p := &printer{}
+
+	p.line("<?php")
+	p.blank()
+	p.line("declare(strict_types=1);")
+
+	if file.Namespace != "" {
+		p.blank()
+		p.line("namespace " + file.Namespace + ";")
+	}
+
+	if file.Imports != nil {
+		if uses := file.Imports.Uses(); len(uses) > 0 {
+			p.blank()
+			for _, use := range uses {
+				p.line(use.String() + ";")
+			}
+		}
+	}
+
+	p.blank()
+	switch decl := file.Decl.(type) {
+	case *Class:
+		decl.print(p)
+	case *Enum:
+		decl.print(p)
+	}
+
+	return p.String()
+}
+
+// PrintMethod renders a single method indented by level, without a trailing newline.
+// It is meant for templates that lay out a class themselves.
+func PrintMethod(method *Method, level int) string {
+	p := &printer{level: level}
+	method.print(p)
+	return strings.TrimSuffix(p.String(), "\n")
+}
+
+// PrintStmt renders a single statement indented by level, without a trailing newline.
+// It is meant for templates that print constants such as array literals themselves.
+func PrintStmt(stmt Stmt, level int) string {
+	p := &printer{level: level}
+	stmt.printStmt(p)
+	return strings.TrimSuffix(p.String(), "\n")
+}
+
+// PrintEnumCases renders the cases of an enum indented by level, without a trailing
+// newline. It is meant for templates that lay out an enum themselves.
+func PrintEnumCases(enum *Enum, level int) string {
+	p := &printer{level: level}
+	enum.printCases(p)
+	return strings.TrimSuffix(p.String(), "\n")
+}
+
+// String returns the printed lines, terminated by a single newline.
+func (p *printer) String() string {
+	return strings.Join(p.lines, "\n") + "\n"
+}
+
+// line prints a line at the current indentation.
+func (p *printer) line(text string) {
+	if text == "" {
+		p.lines = append(p.lines, "")
+		return
+	}
+	p.lines = append(p.lines, strings.Repeat(indentUnit, p.level)+text)
+}
+
+// docLine prints a docblock content line, without trailing whitespace when empty.
+func (p *printer) docLine(text string) {
+	text = strings.TrimRight(text, " \t")
+	if text == "" {
+		p.line(" *")
+		return
+	}
+	p.line(" * " + text)
+}
+
+// blank prints an empty line, collapsing consecutive ones.
+func (p *printer) blank() {
+	if len(p.lines) > 0 && p.lines[len(p.lines)-1] != "" {
+		p.lines = append(p.lines, "")
+	}
+}
+
+// indent prints the output of fn one level deeper.
+func (p *printer) indent(fn func()) {
+	p.level++
+	fn()
+	p.level--
+}
+
+// withSuffix appends suffix to the last line printed by fn.
+func (p *printer) withSuffix(suffix string, fn func()) {
+	fn()
+	if n := len(p.lines); n > 0 {
+		p.lines[n-1] += suffix
+	}
+}
+
+// members prints groups of class members separated by single blank lines.
+func (p *printer) members(groups ...[]func()) {
+	first := true
+	for _, group := range groups {
+		for _, member := range group {
+			if !first {
+				p.blank()
+			}
+			member()
+			first = false
+		}
+	}
+}
+
+// attributes prints one attribute per line.
+func (p *printer) attributes(attributes []string) {
+	for _, attribute := range attributes {
+		p.line("#[" + attribute + "]")
+	}
+}
+
+func (c *Class) print(p *printer) {
+	c.Doc.print(p)
+	p.attributes(c.Attributes)
+
+	var header []string
+	if c.Abstract {
+		header = append(header, "abstract")
+	}
+	if c.Final {
+		header = append(header, "final")
+	}
+	if c.Readonly {
+		header = append(header, "readonly")
+	}
+	header = append(header, "class", c.Name)
+	if c.Extends != "" {
+		header = append(header, "extends", c.Extends)
+	}
+	if len(c.Implements) > 0 {
+		header = append(header, "implements", strings.Join(c.Implements, ", "))
+	}
+	p.line(strings.Join(header, " "))
+
+	p.line("{")
+	p.indent(func() {
+		p.members(constantPrinters(p, c.Constants), propertyPrinters(p, c.Properties), methodPrinters(p, c.Methods))
+	})
+	p.line("}")
+}
+
+func (e *Enum) print(p *printer) {
+	e.Doc.print(p)
+	p.attributes(e.Attributes)
+
+	header := "enum " + e.Name
+	if e.BackingType != "" {
+		header += ": " + e.BackingType
+	}
+	if len(e.Implements) > 0 {
+		header += " implements " + strings.Join(e.Implements, ", ")
+	}
+	p.line(header)
+
+	p.line("{")
+	p.indent(func() {
+		e.printCases(p)
+		if len(e.Cases) > 0 && len(e.Constants)+len(e.Methods) > 0 {
+			p.blank()
+		}
+		p.members(constantPrinters(p, e.Constants), methodPrinters(p, e.Methods))
+	})
+	p.line("}")
+}
+
+// printCases prints the enum cases. Cases without docs or attributes are kept
+// together without blank lines.
+func (e *Enum) printCases(p *printer) {
+	for i, enumCase := range e.Cases {
+		if i > 0 && (enumCase.isDocumented() || e.Cases[i-1].isDocumented()) {
+			p.blank()
+		}
+		enumCase.print(p)
+	}
+}
+
+// isDocumented reports whether the case has a docblock or attributes.
+func (c *EnumCase) isDocumented() bool {
+	return !c.Doc.IsEmpty() || len(c.Attributes) > 0
+}
+
+func (c *EnumCase) print(p *printer) {
+	c.Doc.print(p)
+	p.attributes(c.Attributes)
+	if c.Value == "" {
+		p.line("case " + c.Name + ";")
+		return
+	}
+	p.line("case " + c.Name + " = " + c.Value + ";")
+}
+
+func (c *Constant) print(p *printer) {
+	c.Doc.print(p)
+	parts := []string{string(visibilityOrPublic(c.Visibility)), "const"}
+	if c.Type != "" {
+		parts = append(parts, c.Type)
+	}
+	parts = append(parts, c.Name, "=", c.Value)
+	p.line(strings.Join(parts, " ") + ";")
+}
+
+func (prop *Property) print(p *printer) {
+	prop.Doc.print(p)
+	parts := []string{string(visibilityOrPublic(prop.Visibility))}
+	if prop.Static {
+		parts = append(parts, "static")
+	}
+	if prop.Readonly {
+		parts = append(parts, "readonly")
+	}
+	if prop.Type != "" {
+		parts = append(parts, prop.Type)
+	}
+	declaration := strings.Join(append(parts, "$"+prop.Name), " ")
+	if prop.Default != "" {
+		declaration += " = " + prop.Default
+	}
+	p.line(declaration + ";")
+}
+
+func (m *Method) print(p *printer) {
+	m.Doc.print(p)
+	p.attributes(m.Attributes)
+
+	var modifiers []string
+	if m.Abstract {
+		modifiers = append(modifiers, "abstract")
+	}
+	if m.Final {
+		modifiers = append(modifiers, "final")
+	}
+	modifiers = append(modifiers, string(visibilityOrPublic(m.Visibility)))
+	if m.Static {
+		modifiers = append(modifiers, "static")
+	}
+	head := strings.Join(modifiers, " ") + " function " + m.Name + "("
+
+	returnType := ""
+	if m.ReturnType != "" {
+		returnType = ": " + m.ReturnType
+	}
+
+	params := make([]string, 0, len(m.Params))
+	multiline := false
+	for _, param := range m.Params {
+		params = append(params, param.String())
+		if param.Promote != "" || len(param.Attributes) > 0 || !param.Doc.IsEmpty() {
+			multiline = true
+		}
+	}
+
+	split := multiline && len(params) > 0
+	if len(strings.Repeat(indentUnit, p.level))+len(head+strings.Join(params, ", ")+")"+returnType+" {}") > maxLineLength {
+		split = len(params) > 0
+	}
+
+	// What follows the closing parenthesis; PER-CS abbreviates empty bodies, such as
+	// constructors using property promotion, to {} and puts the opening brace on the
+	// closing parenthesis line when the parameters are split
+	tail := returnType
+	switch {
+	case m.Abstract:
+		tail += ";"
+	case len(m.Body) == 0:
+		tail += " {}"
+	case split:
+		tail += " {"
+	}
+
+	if split {
+		p.line(head)
+		p.indent(func() {
+			for _, param := range m.Params {
+				param.Doc.print(p)
+				p.attributes(param.Attributes)
+				p.line(param.String() + ",")
+			}
+		})
+		p.line(")" + tail)
+	} else {
+		p.line(head + strings.Join(params, ", ") + ")" + tail)
+	}
+
+	if m.Abstract || len(m.Body) == 0 {
+		return
+	}
+	if !split {
+		p.line("{")
+	}
+	p.indent(func() {
+		for _, stmt := range m.Body {
+			stmt.printStmt(p)
+		}
+	})
+	p.line("}")
+}
+
+// String renders the parameter as it appears in a signature.
+func (param *Param) String() string {
+	var parts []string
+	if param.Promote != "" {
+		parts = append(parts, string(param.Promote))
+		if param.Readonly {
+			parts = append(parts, "readonly")
+		}
+	}
+	if param.Type != "" {
+		parts = append(parts, param.Type)
+	}
+	name := "$" + param.Name
+	if param.Variadic {
+		name = "..." + name
+	}
+	parts = append(parts, name)
+	if param.Default != "" {
+		parts = append(parts, "=", param.Default)
+	}
+	return strings.Join(parts, " ")
+}
+
+// visibilityOrPublic defaults an empty visibility to public, which PER-CS requires to be explicit.
+func visibilityOrPublic(visibility Visibility) Visibility {
+	if visibility == "" {
+		return Public
+	}
+	return visibility
+}
+
+func constantPrinters(p *printer, constants []*Constant) []func() {
+	printers := make([]func(), 0, len(constants))
+	for _, constant := range constants {
+		printers = append(printers, func() { constant.print(p) })
+	}
+	return printers
+}
+
+func propertyPrinters(p *printer, properties []*Property) []func() {
+	printers := make([]func(), 0, len(properties))
+	for _, property := range properties {
+		printers = append(printers, func() { property.print(p) })
+	}
+	return printers
+}
+
+func methodPrinters(p *printer, methods []*Method) []func() {
+	printers := make([]func(), 0, len(methods))
+	for _, method := range methods {
+		printers = append(printers, func() { method.print(p) })
+	}
+	return printers
+}
@@ -0,0 +1,183 @@
+package php_test
+
+import (
+	"testing"
+
+	"github.com/floriscornel/piak/internal/php"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrint_Class(t *testing.T) {
+	imports := php.NewImports(`App\Models`, "Pet")
+	exception := imports.Name(`\InvalidArgumentException`)
+
+	file := &php.File{
+		Namespace: `App\Models`,
+		Imports:   imports,
+		Decl: &php.Class{
+			Name:     "Pet",
+			Doc:      php.NewDocBlock("A pet.\nSecond line."),
+			Final:    true,
+			Readonly: true,
+			Methods: []*php.Method{
+				{
+					Name: "__construct",
+					Params: []*php.Param{
+						{Name: "name", Type: "string", Promote: php.Public},
+						{Name: "tag", Type: "?string", Default: "null", Promote: php.Public},
+					},
+				},
+				{
+					Name:       "fromArray",
+					Doc:        php.NewDocBlock().Tag("param", "array<string, mixed> $data"),
+					Static:     true,
+					Params:     []*php.Param{{Name: "data", Type: "array"}},
+					ReturnType: "self",
+					Body: []php.Stmt{
+						php.If("!isset($data['name'])",
+							php.Line("throw new "+exception+"('Missing required field: name');"),
+						),
+						php.BlankLine{},
+						&php.List{
+							Open:  "return new self(",
+							Items: php.Lines("$data['name']", "$data['tag'] ?? null"),
+							Close: ");",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	expected := `<?php
+
+declare(strict_types=1);
+
+namespace App\Models;
+
+use InvalidArgumentException;
+
+/**
+ * A pet.
+ * Second line.
+ */
+final readonly class Pet
+{
+    public function __construct(
+        public string $name,
+        public ?string $tag = null,
+    ) {}
+
+    /**
+     * @param array<string, mixed> $data
+     */
+    public static function fromArray(array $data): self
+    {
+        if (!isset($data['name'])) {
+            throw new InvalidArgumentException('Missing required field: name');
+        }
+
+        return new self(
+            $data['name'],
+            $data['tag'] ?? null,
+        );
+    }
+}
+`
+	assert.Equal(t, expected, php.Print(file))
+}
+
+func TestPrint_Enum(t *testing.T) {
+	file := &php.File{
+		Namespace: "App",
+		Decl: &php.Enum{
+			Name:        "Status",
+			BackingType: "string",
+			Cases: []*php.EnumCase{
+				{Name: "Available", Value: "'available'"},
+				{Name: "Sold", Value: "'sold'"},
+				{Name: "Pending", Value: "'pending'", Doc: php.NewDocBlock().Tag("deprecated", "")},
+			},
+		},
+	}
+
+	expected := `<?php
+
+declare(strict_types=1);
+
+namespace App;
+
+enum Status: string
+{
+    case Available = 'available';
+    case Sold = 'sold';
+
+    /**
+     * @deprecated
+     */
+    case Pending = 'pending';
+}
+`
+	assert.Equal(t, expected, php.Print(file))
+}
+
+func TestPrintMethod_SplitsLongSignatures(t *testing.T) {
+	method := &php.Method{
+		Name:       "withLongName",
+		Params:     []*php.Param{{Name: "firstArgument", Type: "string"}, {Name: "secondArgument", Type: "array"}},
+		ReturnType: "static",
+		Body:       php.Lines("return $this;"),
+	}
+
+	assert.Equal(t, `    public function withLongName(string $firstArgument, array $secondArgument): static
+    {
+        return $this;
+    }`, php.PrintMethod(method, 1))
+
+	method.Name = "withAVeryLongMethodNameThatKeepsGoingAndGoingSoTheSignatureNoLongerFitsOnOneLine"
+	assert.Equal(t, `    public function withAVeryLongMethodNameThatKeepsGoingAndGoingSoTheSignatureNoLongerFitsOnOneLine(
+        string $firstArgument,
+        array $secondArgument,
+    ): static {
+        return $this;
+    }`, php.PrintMethod(method, 1))
+}
+
+func TestPrintMethod_DocumentsPromotedParams(t *testing.T) {
+	method := &php.Method{
+		Name: "__construct",
+		Params: []*php.Param{
+			{Name: "name", Type: "string", Promote: php.Public},
+			{Name: "tag", Type: "string", Promote: php.Public, Doc: php.NewDocBlock().Tag("deprecated", "")},
+		},
+	}
+
+	assert.Equal(t, `public function __construct(
+    public string $name,
+    /**
+     * @deprecated
+     */
+    public string $tag,
+) {}`, php.PrintMethod(method, 0))
+}
+
+func TestImports_AliasesClashingNames(t *testing.T) {
+	imports := php.NewImports(`App\Api`, "Money")
+
+	assert.Equal(t, "Pet", imports.Name("Pet"))
+	assert.Equal(t, "BigDecimal", imports.Name(`\Brick\Math\BigDecimal`))
+	assert.Equal(t, "BigDecimal", imports.Name(`\Brick\Math\BigDecimal`), "imports are de-duplicated")
+	assert.Equal(t, "OtherBigDecimal", imports.Name(`\Other\BigDecimal`))
+	assert.Equal(t, "MoneyMoney", imports.Name(`\Money\Money`), "declared names are never reused")
+	assert.Equal(t, "Pet", imports.Name("PET"), "class names are case-insensitive")
+
+	var uses []string
+	for _, use := range imports.Uses() {
+		uses = append(uses, use.String())
+	}
+	assert.Equal(t, []string{
+		`use Brick\Math\BigDecimal`,
+		`use Money\Money as MoneyMoney`,
+		`use Other\BigDecimal as OtherBigDecimal`,
+	}, uses)
+}
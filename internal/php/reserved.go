@@ -0,0 +1,59 @@
+package php
+
+import "strings"
+
+// reservedWords cannot be used as class names at all.
+var reservedWords = toSet(
+	// Keywords
+	"abstract", "and", "array", "as", "break", "callable", "case", "catch", "class", "clone", "const",
+	"continue", "declare", "default", "die", "do", "echo", "else", "elseif", "empty", "enddeclare",
+	"endfor", "endforeach", "endif", "endswitch", "endwhile", "eval", "exit", "extends", "final",
+	"finally", "fn", "for", "foreach", "function", "global", "goto", "if", "implements", "include",
+	"include_once", "instanceof", "insteadof", "interface", "isset", "list", "match", "namespace",
+	"new", "or", "print", "private", "protected", "public", "readonly", "require", "require_once",
+	"return", "static", "switch", "throw", "trait", "try", "unset", "use", "var", "while", "xor",
+	"yield", "__halt_compiler",
+	// Type names
+	"bool", "false", "float", "int", "iterable", "mixed", "never", "null", "numeric", "object",
+	"parent", "resource", "self", "string", "true", "void",
+)
+
+// builtinClasses are global classes and interfaces that a generated class would
+// shadow, making code in the same namespace refer to the wrong type.
+var builtinClasses = toSet(
+	"ArrayAccess", "ArrayIterator", "ArrayObject", "Attribute", "BackedEnum", "Closure", "Countable",
+	"DateInterval", "DatePeriod", "DateTime", "DateTimeImmutable", "DateTimeInterface", "DateTimeZone",
+	"Directory", "Error", "ErrorException", "Exception", "Fiber", "Generator", "InvalidArgumentException",
+	"Iterator", "IteratorAggregate", "JsonException", "JsonSerializable", "LogicException",
+	"RuntimeException", "Serializable", "SplObjectStorage", "stdClass", "Stringable", "Throwable",
+	"Traversable", "TypeError", "UnexpectedValueException", "UnitEnum", "ValueError", "WeakMap",
+	"WeakReference",
+)
+
+// builtinTypes are the type names that can appear in a declaration besides classes.
+var builtinTypes = toSet(
+	"array", "bool", "callable", "false", "float", "int", "iterable", "mixed", "never", "null",
+	"object", "self", "static", "string", "true", "void",
+)
+
+// IsBuiltinType reports whether name is a builtin type rather than a class.
+func IsBuiltinType(name string) bool {
+	return builtinTypes[strings.ToLower(name)]
+}
+
+// IsReservedClassName reports whether name cannot or should not be used as the name
+// of a generated class: reserved words, type names and common builtin classes.
+// The comparison ignores case, as PHP class names do.
+func IsReservedClassName(name string) bool {
+	lower := strings.ToLower(name)
+	return reservedWords[lower] || builtinClasses[lower]
+}
+
+// toSet builds a lower-case lookup set.
+func toSet(names ...string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[strings.ToLower(name)] = true
+	}
+	return set
+}
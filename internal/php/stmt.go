@@ -0,0 +1,98 @@
+package php
+
+// Stmt is a statement in a method body.
+type Stmt interface {
+	printStmt(p *printer)
+}
+
+// Line is a single line of code, printed as is at the current indentation.
+type Line string
+
+// BlankLine separates groups of statements.
+type BlankLine struct{}
+
+// Block is a statement with a braced body, such as an if or foreach. Header is
+// the text before the opening brace; Footer defaults to "}" and can continue
+// the statement, e.g. "} else {". Continue is the block continuing the statement
+// after the closing brace, such as a catch block.
+type Block struct {
+	Header   string
+	Body     []Stmt
+	Footer   string
+	Continue *Block
+}
+
+// List is a multi-line list such as call arguments or an array literal. Every
+// item is printed on its own line with a trailing comma, as PER-CS prescribes.
+// An empty list is printed on a single line.
+type List struct {
+	Open  string
+	Items []Stmt
+	Close string
+}
+
+// Lines converts strings to Line statements.
+func Lines(lines ...string) []Stmt {
+	stmts := make([]Stmt, 0, len(lines))
+	for _, line := range lines {
+		stmts = append(stmts, Line(line))
+	}
+	return stmts
+}
+
+// If builds an if block.
+func If(condition string, body ...Stmt) *Block {
+	return &Block{Header: "if (" + condition + ")", Body: body}
+}
+
+// Try builds a try block with a single catch block, catching the exceptions
+// declared by catch, such as "ValidationException $exception".
+func Try(body []Stmt, catch string, handler ...Stmt) *Block {
+	return &Block{Header: "try", Body: body, Continue: &Block{Header: "catch (" + catch + ")", Body: handler}}
+}
+
+// Foreach builds a foreach block.
+func Foreach(expression string, body ...Stmt) *Block {
+	return &Block{Header: "foreach (" + expression + ")", Body: body}
+}
+
+func (l Line) printStmt(p *printer) {
+	p.line(string(l))
+}
+
+func (BlankLine) printStmt(p *printer) {
+	p.blank()
+}
+
+func (b *Block) printStmt(p *printer) {
+	p.line(b.Header + " {")
+	for block := b; block != nil; block = block.Continue {
+		p.indent(func() {
+			for _, stmt := range block.Body {
+				stmt.printStmt(p)
+			}
+		})
+		footer := block.Footer
+		if footer == "" {
+			footer = "}"
+		}
+		if block.Continue != nil {
+			footer += " " + block.Continue.Header + " {"
+		}
+		p.line(footer)
+	}
+}
+
+func (l *List) printStmt(p *printer) {
+	if len(l.Items) == 0 {
+		p.line(l.Open + l.Close)
+		return
+	}
+	p.line(l.Open)
+	p.indent(func() {
+		for _, item := range l.Items {
+			p.withSuffix(",", func() { item.printStmt(p) })
+		}
+	})
+	p.line(l.Close)
+}
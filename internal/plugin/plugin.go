@@ -0,0 +1,153 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/floriscornel/piak/internal/config"
+)
+
+// ProtocolVersion is the version of the request/response format exchanged with plugins.
+// It is incremented on incompatible changes only.
+const ProtocolVersion = 1
+
+// ExecutablePrefix is prepended to a plugin name to find its executable on the PATH.
+const ExecutablePrefix = "piak-gen-"
+
+// Diagnostic severities a plugin can report.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Request is written as JSON to the plugin's stdin.
+type Request struct {
+	ProtocolVersion int                   `json:"protocol_version"`
+	Plugin          string                `json:"plugin"`
+	Parameter       string                `json:"parameter"`
+	Model           *config.InternalModel `json:"model"`
+}
+
+// Response is read as JSON from the plugin's stdout.
+type Response struct {
+	Files       []File       `json:"files"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// File is a file to write, with a slash-separated path relative to the output directory.
+type File struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// Diagnostic is a message reported by a plugin.
+type Diagnostic struct {
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// Plugin is an external generator invoked over the stdin/stdout protocol.
+type Plugin struct {
+	Name      string
+	Parameter string
+}
+
+// Parse parses a plugin specification of the form "name" or "name:parameter".
+func Parse(spec string) (*Plugin, error) {
+	name, parameter, _ := strings.Cut(spec, ":")
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid plugin name: %q", spec)
+	}
+
+	return &Plugin{Name: name, Parameter: parameter}, nil
+}
+
+// Executable returns the name of the plugin's executable.
+func (p *Plugin) Executable() string {
+	return ExecutablePrefix + p.Name
+}
+
+// Run executes the plugin with the given model and returns its validated response.
+// The plugin's stderr is passed through so it can log progress. A plugin that exits
+// with a non-zero status or reports an error diagnostic fails the run.
+func (p *Plugin) Run(model *config.InternalModel) (*Response, error) {
+	executable, err := exec.LookPath(p.Executable())
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s not found: %w", p.Name, err)
+	}
+
+	input, err := json.Marshal(Request{
+		ProtocolVersion: ProtocolVersion,
+		Plugin:          p.Name,
+		Parameter:       p.Parameter,
+		Model:           model,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode plugin request: %w", err)
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.Command(executable)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+
+	if runErr := cmd.Run(); runErr != nil {
+		return nil, fmt.Errorf("plugin %s failed: %w", p.Name, runErr)
+	}
+
+	var response Response
+	if decodeErr := json.Unmarshal(stdout.Bytes(), &response); decodeErr != nil {
+		return nil, fmt.Errorf("plugin %s returned an invalid response: %w", p.Name, decodeErr)
+	}
+
+	if validateErr := response.validate(); validateErr != nil {
+		return nil, fmt.Errorf("plugin %s returned an invalid response: %w", p.Name, validateErr)
+	}
+
+	var errs []string
+	for _, diagnostic := range response.Diagnostics {
+		if diagnostic.Severity == SeverityError {
+			errs = append(errs, diagnostic.Message)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("plugin %s reported errors:\n  - %s", p.Name, strings.Join(errs, "\n  - "))
+	}
+
+	return &response, nil
+}
+
+// validate checks that every file is inside the output directory, rather than
+// outside it or the directory itself, and every diagnostic has a known severity.
+func (r *Response) validate() error {
+	seen := make(map[string]bool, len(r.Files))
+	for _, file := range r.Files {
+		cleaned := path.Clean(file.Path)
+		if file.Path == "" || cleaned == "." || path.IsAbs(cleaned) || cleaned == ".." ||
+			strings.HasPrefix(cleaned, "../") || strings.Contains(file.Path, `\`) {
+			return fmt.Errorf("file path must be relative to the output directory: %q", file.Path)
+		}
+		if seen[cleaned] {
+			return fmt.Errorf("file returned more than once: %s", cleaned)
+		}
+		seen[cleaned] = true
+	}
+
+	for _, diagnostic := range r.Diagnostics {
+		switch diagnostic.Severity {
+		case SeverityError, SeverityWarning, SeverityInfo:
+		default:
+			return errors.New("unknown diagnostic severity: " + diagnostic.Severity)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package plugin_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/floriscornel/piak/internal/plugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// installPlugin writes a shell script plugin that prints response to stdout.
+func installPlugin(t *testing.T, name, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugins are not supported on Windows")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, plugin.ExecutablePrefix+name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestParse(t *testing.T) {
+	p, err := plugin.Parse("rpc:mode=strict")
+	require.NoError(t, err)
+	assert.Equal(t, "rpc", p.Name)
+	assert.Equal(t, "mode=strict", p.Parameter)
+	assert.Equal(t, "piak-gen-rpc", p.Executable())
+
+	p, err = plugin.Parse("di")
+	require.NoError(t, err)
+	assert.Empty(t, p.Parameter)
+
+	for _, invalid := range []string{"", ":param", "../evil", `dir\evil`} {
+		_, err = plugin.Parse(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestRun_ReturnsFilesAndDiagnostics(t *testing.T) {
+	// The plugin echoes the operation count from the request to prove it received the model
+	installPlugin(t, "echo", `count=$(grep -o '"operation_id"' | wc -l | tr -d ' ')
+printf '{"files":[{"path":"src/Rpc/Wiring.php","content":"%s"}],' "$count"
+printf '"diagnostics":[{"severity":"warning","message":"experimental"}]}'`)
+
+	model := &config.InternalModel{
+		Operations: []*config.OperationModel{{OperationID: "listPets"}, {OperationID: "getPet"}},
+	}
+
+	p, err := plugin.Parse("echo")
+	require.NoError(t, err)
+
+	response, err := p.Run(model)
+	require.NoError(t, err)
+	require.Len(t, response.Files, 1)
+	assert.Equal(t, "src/Rpc/Wiring.php", response.Files[0].Path)
+	assert.Equal(t, "2", response.Files[0].Content)
+	require.Len(t, response.Diagnostics, 1)
+	assert.Equal(t, plugin.SeverityWarning, response.Diagnostics[0].Severity)
+}
+
+func TestRun_Failures(t *testing.T) {
+	tests := []struct {
+		name        string
+		script      string
+		expectedErr string
+	}{
+		{
+			name:        "non-zero exit",
+			script:      "cat >/dev/null; exit 3",
+			expectedErr: "failed",
+		},
+		{
+			name:        "invalid json",
+			script:      "cat >/dev/null; echo nope",
+			expectedErr: "invalid response",
+		},
+		{
+			name:        "path escapes output directory",
+			script:      `cat >/dev/null; echo '{"files":[{"path":"../outside.php","content":""}]}'`,
+			expectedErr: "must be relative",
+		},
+		{
+			name:        "output directory",
+			script:      `cat >/dev/null; echo '{"files":[{"path":"src/..","content":""}]}'`,
+			expectedErr: "must be relative",
+		},
+		{
+			name:        "absolute path",
+			script:      `cat >/dev/null; echo '{"files":[{"path":"/etc/passwd","content":""}]}'`,
+			expectedErr: "must be relative",
+		},
+		{
+			name:        "error diagnostic",
+			script:      `cat >/dev/null; echo '{"diagnostics":[{"severity":"error","message":"unsupported spec"}]}'`,
+			expectedErr: "unsupported spec",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installPlugin(t, "broken", tt.script)
+
+			p, err := plugin.Parse("broken")
+			require.NoError(t, err)
+
+			_, err = p.Run(&config.InternalModel{})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedErr)
+		})
+	}
+}
+
+func TestRun_MissingPlugin(t *testing.T) {
+	p, err := plugin.Parse("does-not-exist-anywhere")
+	require.NoError(t, err)
+
+	_, err = p.Run(&config.InternalModel{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+}
@@ -0,0 +1,608 @@
+package templates
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/floriscornel/piak/internal/naming"
+	"github.com/floriscornel/piak/internal/php"
+)
+
+// Classes of the cURL transport generated next to the client, which uses it when no
+// PSR-18 client is installed.
+const (
+	CurlClientClass           = "CurlHttpClient"
+	CurlNetworkExceptionClass = "CurlNetworkException"
+)
+
+// RetryPolicyClass is the class of the retry policy the client takes.
+const RetryPolicyClass = "RetryPolicy"
+
+// ServerSentEventClass is the class of the events of text/event-stream responses.
+const ServerSentEventClass = "ServerSentEvent"
+
+// pathTemplateParam matches a parameter such as {petId} in a path template.
+var pathTemplateParam = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// NewClientData builds the template data for the API client, including a method
+// per operation, followed by its iterator for paginated ones.
+func NewClientData(model *config.InternalModel, cfg *config.GeneratorConfig) ClientData {
+	data := ClientData{
+		InternalModel: model,
+		Config:        cfg,
+	}
+	imports := make(map[string]bool)
+	addImport := func(className, dataType string) {
+		if className != "" && !imports[className] {
+			imports[className] = true
+			data.TypeImports = append(data.TypeImports, dataType+" from "+className)
+		}
+	}
+	for _, op := range model.Operations {
+		data.Methods = append(data.Methods, buildOperationMethod(op, cfg))
+		for _, response := range op.ErrorResponses {
+			addImport(response.ClassName, response.DataType)
+		}
+		if op.Response != nil {
+			addImport(op.Response.ItemClass, op.Response.ItemDataType)
+		}
+		if op.Pagination != nil {
+			data.Methods = append(data.Methods, buildIteratorMethod(op, cfg))
+			data.Pagination = true
+			addImport(op.Pagination.ItemClass, op.Pagination.ItemDataType)
+		}
+	}
+	sort.Strings(data.TypeImports)
+	for _, scheme := range model.SecuritySchemes {
+		data.CredentialSetters = append(data.CredentialSetters, buildCredentialSetter(scheme))
+		data.OAuth2 = data.OAuth2 || scheme.Kind == config.SecurityOAuth2
+	}
+	data.ServerList = serverList(model.Servers)
+	data.OperationServerList = operationServerList(model.Operations)
+	for _, server := range model.Servers {
+		data.ServerConstructors = append(data.ServerConstructors, buildServerConstructor(server))
+	}
+	if len(model.Servers) > 0 {
+		data.DefaultServerURL = serverURL(model.Servers[0])
+	}
+	return data
+}
+
+// buildCredentialSetter builds the method setting the credentials of a security
+// scheme, which requests of operations accepting the scheme are authenticated with.
+func buildCredentialSetter(scheme *config.SecuritySchemeModel) *php.Method {
+	var summary string
+	var params []string
+	switch scheme.Kind {
+	case config.SecurityAPIKey:
+		summary = fmt.Sprintf("Set the API key of the %s security scheme, sent in the %s %s",
+			scheme.Name, scheme.ParamName, apiKeyLocations[scheme.In])
+		params = []string{"apiKey"}
+	case config.SecurityBasic:
+		summary = fmt.Sprintf("Set the username and password of the %s security scheme", scheme.Name)
+		params = []string{"username", "password"}
+	case config.SecurityBearer:
+		summary = fmt.Sprintf("Set the bearer token of the %s security scheme", scheme.Name)
+		params = []string{"token"}
+	case config.SecurityOAuth2:
+		summary = fmt.Sprintf("Set the OAuth2 client credentials of the %s security scheme, "+
+			"which access tokens are fetched with", scheme.Name)
+		params = []string{"clientId", "clientSecret"}
+	}
+
+	doc := php.NewDocBlock(summary)
+	if scheme.Description != "" {
+		doc.Lines = append(doc.Lines, "", php.MarkdownText(scheme.Description))
+	}
+	method := &php.Method{Name: scheme.SetterName, Doc: doc, ReturnType: "static"}
+	items := make([]string, 0, len(params))
+	for _, param := range params {
+		method.Params = append(method.Params, &php.Param{Name: param, Type: "string"})
+		items = append(items, fmt.Sprintf("%s => $%s", php.StringLiteral(param), param))
+	}
+
+	name := php.StringLiteral(scheme.Name)
+	method.Body = append(method.Body,
+		php.Line(fmt.Sprintf("$this->credentials[%s] = [%s];", name, strings.Join(items, ", "))))
+	if scheme.Kind == config.SecurityOAuth2 {
+		// Tokens fetched with other credentials must not be used anymore
+		method.Body = append(method.Body, php.Line(fmt.Sprintf("unset($this->accessTokens[%s]);", name)))
+	}
+	method.Body = append(method.Body, php.BlankLine{}, php.Line("return $this;"))
+	return method
+}
+
+// apiKeyLocations describes where API keys are sent.
+var apiKeyLocations = map[string]string{
+	"header": "header",
+	"query":  "query parameter",
+	"cookie": "cookie",
+}
+
+// buildOperationMethod builds the client method calling an operation. Path
+// parameters are arguments; query parameters and the body are passed in $data, as
+// request() expects them. Binary responses are returned as a stream, which an
+// optional sink receives, NDJSON ones yielded value by value and event streams event
+// by event, their values and data hydrated into their model if any.
+func buildOperationMethod(op *config.OperationModel, cfg *config.GeneratorConfig) *php.Method {
+	method, args := newOperationMethod(op, cfg, op.MethodName, operationDescription(op))
+	kind := config.ResponseJSON
+	if op.Response != nil {
+		kind = op.Response.Kind
+		if kind != config.ResponseJSON {
+			// The headers given by the caller take precedence
+			args[3] = fmt.Sprintf("$headers + ['Accept' => %s]",
+				php.StringLiteral(strings.Join(op.Response.MediaTypes, ", ")))
+		}
+	}
+
+	switch kind {
+	case config.ResponseBinary:
+		method.ReturnType = "StreamInterface"
+		method.Params = append(method.Params, &php.Param{Name: "sink", Type: "mixed", Default: "null"})
+		method.Doc.Tag("param", "resource|string|StreamInterface|null $sink File path, resource or stream the body "+
+			"is\n    copied to in chunks").
+			Tag("return", "StreamInterface The body, read to its end when copied to a sink")
+		addThrowsTags(method.Doc, op)
+		method.Body = append(method.Body, callStatement("return $this->requestStream(", append(args, "sink: $sink"), op))
+	case config.ResponseNDJSON:
+		itemClass := op.Response.ItemClass
+		method.ReturnType = `\Generator`
+		method.Doc.Tag("return", fmt.Sprintf(`\Generator<int, %s>`, cmp.Or(itemClass, "mixed")))
+		addThrowsTags(method.Doc, op)
+		if itemClass == "" {
+			method.Body = append(method.Body, callStatement("yield from $this->requestLines(", args, op))
+			break
+		}
+		method.Body = append(method.Body,
+			callStatement("$lines = $this->requestLines(", args, op),
+			php.Foreach("$lines as $line", php.Lines(
+				fmt.Sprintf("/** @var %s $line */", op.Response.ItemDataType),
+				fmt.Sprintf("yield %s::fromArray($line);", itemClass),
+			)...),
+		)
+	case config.ResponseEvents:
+		addEventStreamCall(method, op, args)
+	default:
+		method.ReturnType = "array"
+		method.Doc.Tag("return", "array<mixed>")
+		addThrowsTags(method.Doc, op)
+		method.Body = append(method.Body, callStatement("return $this->request(", args, op))
+	}
+	return method
+}
+
+// addEventStreamCall completes the method of an operation responding with server-sent
+// events, which yields the events with their data hydrated into its model, if any.
+// It takes the ID of the last event received to resume a stream.
+func addEventStreamCall(method *php.Method, op *config.OperationModel, args []string) {
+	response := op.Response
+	dataType := "string"
+	if response.JSONEvents {
+		dataType = cmp.Or(response.ItemClass, "mixed")
+		args = append(args, "json: true")
+	}
+	method.ReturnType = `\Generator`
+	method.Params = append(method.Params, &php.Param{Name: "lastEventId", Type: "?string", Default: "null"})
+	method.Doc.Tag("param", "string|null $lastEventId ID of the last event received before, to resume the "+
+		"stream\n    after it").
+		Tag("return", fmt.Sprintf(`\Generator<int, %s<%s>>`, ServerSentEventClass, dataType))
+	addThrowsTags(method.Doc, op)
+
+	args = append(args, "lastEventId: $lastEventId")
+	if response.ItemClass == "" {
+		method.Body = append(method.Body, callStatement("yield from $this->requestEvents(", args, op))
+		return
+	}
+	method.Body = append(method.Body,
+		callStatement("$events = $this->requestEvents(", args, op),
+		php.Foreach("$events as $event", php.Lines(
+			fmt.Sprintf("/** @var %s $eventData */", response.ItemDataType),
+			"$eventData = $event->data;",
+			fmt.Sprintf("yield $event->withData(%s::fromArray($eventData));", response.ItemClass),
+		)...),
+	)
+}
+
+// buildIteratorMethod builds the method iterating over the items of a paginated
+// operation, across all of its pages. It takes the arguments of the operation
+// method and yields models when the items are.
+func buildIteratorMethod(op *config.OperationModel, cfg *config.GeneratorConfig) *php.Method {
+	pagination := op.Pagination
+	description := operationDescription(op)
+	description = append(description, "",
+		"Iterates over the items of all pages, requesting the next page once the items of",
+		"the previous one have been consumed.")
+	method, args := newOperationMethod(op, cfg, pagination.IteratorName, description)
+	method.ReturnType = `\Generator`
+	itemType := "mixed"
+	if pagination.ItemClass != "" {
+		itemType = pagination.ItemClass
+	}
+	method.Doc.Tag("return", fmt.Sprintf(`\Generator<int, %s>`, itemType))
+	addThrowsTags(method.Doc, op)
+
+	args = append([]string{paginationLiteral(pagination)}, args...)
+	if pagination.ItemClass == "" {
+		method.Body = append(method.Body, callStatement("yield from $this->paginate(", args, op))
+		return method
+	}
+	method.Body = append(method.Body,
+		callStatement("$items = $this->paginate(", args, op),
+		php.Foreach("$items as $item", php.Lines(
+			fmt.Sprintf("/** @var %s $item */", pagination.ItemDataType),
+			fmt.Sprintf("yield %s::fromArray($item);", pagination.ItemClass),
+		)...),
+	)
+	return method
+}
+
+// newOperationMethod starts a method sending the request of an operation, with its
+// parameters and their tags, and returns the arguments of the request. The caller
+// adds the return type, the remaining tags and the call itself.
+func newOperationMethod(
+	op *config.OperationModel, cfg *config.GeneratorConfig, name string, description []string,
+) (*php.Method, []string) {
+	doc := php.NewDocBlock(description...)
+	method := &php.Method{Name: name, Doc: doc}
+
+	// Path parameters come first, in the order they appear in the path
+	var wireNames []string
+	for _, match := range pathTemplateParam.FindAllStringSubmatch(op.Path, -1) {
+		wireNames = append(wireNames, match[1])
+	}
+	argNames := naming.PropertyNames(wireNames, naming.CamelCase)
+	segments := make(map[string]string, len(wireNames))
+	for _, wireName := range wireNames {
+		name := argNames[wireName]
+		if name == "body" || name == "data" || name == "headers" || name == "sink" || name == "lastEventId" {
+			name = "path" + naming.Pascal(name)
+		}
+
+		param := findParameter(op, "path", wireName)
+		typ := pathParamType(param, cfg)
+		style := "simple"
+		if param != nil && param.Style != "" {
+			style = param.Style
+		}
+		switch {
+		case typ == "array" || style != "simple":
+			// Lists, maps and the label and matrix styles need the serialization rules of OpenAPI
+			segments[wireName] = fmt.Sprintf("self::serializeParameter(%s, $%s, %s, %t)",
+				php.StringLiteral(wireName), name, php.StringLiteral(style), param.Explode)
+		case typ == "string":
+			segments[wireName] = fmt.Sprintf("rawurlencode($%s)", name)
+		default:
+			segments[wireName] = fmt.Sprintf("rawurlencode((string) $%s)", name)
+		}
+		method.Params = append(method.Params, &php.Param{Name: name, Type: typ})
+		docType := typ
+		if typ == "array" {
+			docType = "array<mixed>"
+		}
+		doc.Tag("param", strings.TrimSpace(fmt.Sprintf("%s $%s %s", docType, name, parameterDescription(param))))
+	}
+
+	raw := op.RequestBody != nil && op.RequestBody.MediaType == config.MediaTypeOctetStream
+	if raw {
+		// Raw bodies are sent as given rather than encoded from $data
+		param := &php.Param{Name: "body", Type: "mixed"}
+		docType := `string|resource|StreamInterface`
+		if !op.RequestBody.Required {
+			param.Default = "null"
+			docType += "|null"
+		}
+		method.Params = append(method.Params, param)
+		doc.Tag("param", docType+" $body The body, sent as is")
+	}
+	method.Params = append(method.Params,
+		&php.Param{Name: "data", Type: "array", Default: "[]"},
+		&php.Param{Name: "headers", Type: "array", Default: "[]"},
+	)
+	dataDescription := "array<string, mixed> $data Query, header and cookie parameters by name; other values are\n" +
+		"    query parameters for GET and DELETE, the body otherwise"
+	switch {
+	case raw:
+		dataDescription = "array<string, mixed> $data Query, header and cookie parameters by name; other values are\n" +
+			"    query parameters"
+	case op.RequestBody != nil && op.RequestBody.MediaType == config.MediaTypeMultipart:
+		dataDescription += ". Files are resources, PSR-7 streams,\n    SplFileInfo objects or paths"
+	}
+	doc.Tag("param", dataDescription).
+		Tag("param", "array<string, string> $headers Additional headers")
+
+	if op.Deprecated {
+		doc.Tag("deprecated", "")
+		if cfg.DeprecatedAttributes {
+			// PHP itself raises E_USER_DEPRECATED when the method is called
+			method.Attributes = append(method.Attributes, `\Deprecated`)
+		} else if cfg.DeprecationNotices {
+			message := fmt.Sprintf("Operation %s (%s %s) is deprecated",
+				op.OperationID, strings.ToUpper(op.Method), op.Path)
+			method.Body = append(method.Body,
+				php.Line(fmt.Sprintf("trigger_error(%s, E_USER_DEPRECATED);", php.StringLiteral(message))))
+		}
+	}
+
+	endpoint := endpointExpression(op.Path, segments)
+	if len(op.Servers) > 0 {
+		// The operation is sent to its own servers rather than the client's
+		endpoint = fmt.Sprintf("$this->operationServer(%s) . %s", php.StringLiteral(op.OperationID), endpoint)
+	}
+	if len(wireNames) > 0 {
+		method.Body = append(method.Body, php.Line(fmt.Sprintf("$endpoint = %s;", endpoint)))
+		endpoint = "$endpoint"
+	}
+	args := []string{php.StringLiteral(strings.ToUpper(op.Method)), endpoint, "$data", "$headers"}
+	if len(op.Security) > 0 {
+		args = append(args, securityLiteral(op.Security))
+	}
+	if op.Retryable != nil {
+		args = append(args, fmt.Sprintf("retryable: %t", *op.Retryable))
+	}
+	if op.RequestBody != nil && op.RequestBody.MediaType != config.MediaTypeJSON {
+		args = append(args, "contentType: "+php.StringLiteral(op.RequestBody.MediaType))
+	}
+	if raw {
+		args = append(args, "body: $body")
+	}
+	return method, args
+}
+
+// addThrowsTags adds the @throws tags of the error responses of an operation.
+func addThrowsTags(doc *php.DocBlock, op *config.OperationModel) {
+	for _, response := range op.ErrorResponses {
+		doc.Tag("throws", strings.TrimSpace(response.Exception+" "+errorDescription(response)))
+	}
+	doc.Tag("throws", `\Exception`)
+}
+
+// maxCallWidth is the longest call printed on a single line, leaving room for the
+// indentation of a method body within the 120 columns of the generated code.
+const maxCallWidth = 112
+
+// callStatement returns the statement calling request() or paginate() with the
+// given arguments, followed by the error hydrators and parameters of the operation,
+// opened by open, such as "return $this->request(".
+func callStatement(open string, args []string, op *config.OperationModel) php.Stmt {
+	var lists []php.Stmt
+	if hydrators := errorHydrators(op); len(hydrators) > 0 {
+		lists = append(lists, &php.List{Open: "errors: [", Items: hydrators, Close: "]"})
+	}
+	if parameters := parameterItems(op); len(parameters) > 0 {
+		lists = append(lists, &php.List{Open: "parameters: [", Items: parameters, Close: "]"})
+	}
+	if encoding := encodingItems(op); len(encoding) > 0 {
+		lists = append(lists, &php.List{Open: "encoding: [", Items: encoding, Close: "]"})
+	}
+	line := open + strings.Join(args, ", ") + ");"
+	if len(lists) == 0 && len(line) <= maxCallWidth {
+		return php.Line(line)
+	}
+
+	// Every argument gets its own line, the lists spanning several
+	call := &php.List{Open: open, Items: php.Lines(args...), Close: ");"}
+	call.Items = append(call.Items, lists...)
+	return call
+}
+
+// parameterItems returns the items of the $parameters array passed to request(),
+// describing where and how each query, header and cookie parameter is sent.
+func parameterItems(op *config.OperationModel) []php.Stmt {
+	var items []php.Stmt
+	for _, param := range op.Parameters {
+		if param.In == "path" {
+			continue
+		}
+		style, explode := param.Style, param.Explode
+		if style == "" {
+			// OpenAPI's defaults, for parameters without a resolved style
+			style, explode = "form", true
+			if param.In == "header" {
+				style, explode = "simple", false
+			}
+		}
+		items = append(items, php.Line(fmt.Sprintf("%s => ['in' => %s, 'style' => %s, 'explode' => %t]",
+			php.StringLiteral(param.Name), php.StringLiteral(param.In), php.StringLiteral(style), explode)))
+	}
+	return items
+}
+
+// encodingItems returns the items of the $encoding array passed to request() for
+// multipart bodies, marking the parts holding files and setting the content types
+// the spec sets for parts.
+func encodingItems(op *config.OperationModel) []php.Stmt {
+	body := op.RequestBody
+	if body == nil || body.MediaType != config.MediaTypeMultipart {
+		return nil
+	}
+
+	parts := make(map[string][]string)
+	for _, name := range body.Files {
+		parts[name] = append(parts[name], "'file' => true")
+	}
+	for name, contentType := range body.PartContentTypes {
+		parts[name] = append([]string{"'contentType' => " + php.StringLiteral(contentType)}, parts[name]...)
+	}
+	names := make([]string, 0, len(parts))
+	for name := range parts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	items := make([]php.Stmt, 0, len(names))
+	for _, name := range names {
+		items = append(items, php.Line(fmt.Sprintf("%s => [%s]", php.StringLiteral(name), strings.Join(parts[name], ", "))))
+	}
+	return items
+}
+
+// paginationLiteral returns the pagination of an operation as the PHP array
+// paginate() takes, such as ['style' => 'cursor', 'items' => 'data', ...].
+func paginationLiteral(pagination *config.PaginationModel) string {
+	fields := []struct{ key, value string }{
+		{"style", pagination.Style},
+		{"items", pagination.Items},
+		{"cursorParam", pagination.CursorParam},
+		{"nextCursor", pagination.NextCursor},
+		{"offsetParam", pagination.OffsetParam},
+		{"limitParam", pagination.LimitParam},
+		{"total", pagination.Total},
+	}
+	items := make([]string, 0, len(fields))
+	for i, field := range fields {
+		// Style and items are always passed, items being empty when the body is the list
+		if field.value != "" || i < 2 {
+			items = append(items, fmt.Sprintf("%s => %s", php.StringLiteral(field.key), php.StringLiteral(field.value)))
+		}
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// errorDescription returns the @throws description of an error response: its status
+// and description.
+func errorDescription(response *config.ErrorResponseModel) string {
+	status := response.Status
+	if status == "default" {
+		status = "Other statuses"
+	}
+	if description := php.MarkdownText(response.Description); description != "" {
+		return status + ": " + description
+	}
+	return status
+}
+
+// errorHydrators returns the items of the $errors array passed to request(), a
+// function per error response whose body is a model, keyed by status.
+func errorHydrators(op *config.OperationModel) []php.Stmt {
+	var items []php.Stmt
+	for _, response := range op.ErrorResponses {
+		if response.ClassName == "" {
+			continue
+		}
+		key := response.Status
+		if _, err := strconv.Atoi(key); err != nil {
+			key = php.StringLiteral(key)
+		}
+		items = append(items, &php.Block{
+			Header: fmt.Sprintf("%s => static function (array $error): %s", key, response.ClassName),
+			Body: php.Lines(
+				fmt.Sprintf("/** @var %s $error */", response.DataType),
+				fmt.Sprintf("return %s::fromArray($error);", response.ClassName),
+			),
+		})
+	}
+	return items
+}
+
+// securityLiteral returns the security requirements of an operation as the PHP array
+// request() takes, such as [['petstore_auth' => ['read:pets']], ['api_key' => []]].
+func securityLiteral(security []map[string][]string) string {
+	requirements := make([]string, 0, len(security))
+	for _, requirement := range security {
+		names := make([]string, 0, len(requirement))
+		for name := range requirement {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		schemes := make([]string, 0, len(names))
+		for _, name := range names {
+			scopes := make([]string, 0, len(requirement[name]))
+			for _, scope := range requirement[name] {
+				scopes = append(scopes, php.StringLiteral(scope))
+			}
+			schemes = append(schemes, fmt.Sprintf("%s => [%s]", php.StringLiteral(name), strings.Join(scopes, ", ")))
+		}
+		requirements = append(requirements, "["+strings.Join(schemes, ", ")+"]")
+	}
+	return "[" + strings.Join(requirements, ", ") + "]"
+}
+
+// operationDescription returns the description lines of an operation method: the
+// summary, or the method and path, the description and deprecated parameters.
+func operationDescription(op *config.OperationModel) []string {
+	lines := []string{op.Summary}
+	if op.Summary == "" {
+		lines[0] = strings.ToUpper(op.Method) + " " + op.Path
+	}
+	if op.Description != "" {
+		lines = append(lines, "", php.MarkdownText(op.Description))
+	}
+
+	var deprecated []string
+	for _, param := range op.Parameters {
+		if param.Deprecated && param.In != "path" {
+			deprecated = append(deprecated, fmt.Sprintf("%s (%s)", param.Name, param.In))
+		}
+	}
+	if len(deprecated) > 0 {
+		lines = append(lines, "", "Deprecated parameters: "+strings.Join(deprecated, ", ")+".")
+	}
+	return lines
+}
+
+// findParameter returns the operation parameter with the given location and name,
+// or nil when the spec does not declare it.
+func findParameter(op *config.OperationModel, in, name string) *config.ParameterModel {
+	for _, param := range op.Parameters {
+		if param.In == in && param.Name == name {
+			return param
+		}
+	}
+	return nil
+}
+
+// pathParamType returns the PHP type of a path parameter: int for integer schemas,
+// also accepting numeric strings for int64 ones when those are held as strings, array
+// for array and object schemas, and string for everything else, which request()
+// sends as is.
+func pathParamType(param *config.ParameterModel, cfg *config.GeneratorConfig) string {
+	if param == nil || param.OpenAPIType == nil {
+		return "string"
+	}
+	switch {
+	case param.OpenAPIType.Type.Is("array") || param.OpenAPIType.Type.Is("object"):
+		return "array"
+	case !param.OpenAPIType.Type.Is("integer"):
+		return "string"
+	case param.OpenAPIType.Format == "int64" && cfg.Int64 == config.Int64String:
+		return "int|string"
+	}
+	return "int"
+}
+
+// parameterDescription returns the @param description of a parameter.
+func parameterDescription(param *config.ParameterModel) string {
+	if param == nil {
+		return ""
+	}
+	description := php.MarkdownText(param.Description)
+	if param.Deprecated {
+		description = strings.TrimSpace(description + " Deprecated.")
+	}
+	return description
+}
+
+// endpointExpression returns the PHP expression building the endpoint of a path
+// template from the expressions of its parameters.
+func endpointExpression(path string, segments map[string]string) string {
+	var parts []string
+	last := 0
+	for _, loc := range pathTemplateParam.FindAllStringSubmatchIndex(path, -1) {
+		if loc[0] > last {
+			parts = append(parts, php.StringLiteral(path[last:loc[0]]))
+		}
+		parts = append(parts, segments[path[loc[2]:loc[3]]])
+		last = loc[1]
+	}
+	if last < len(path) || len(parts) == 0 {
+		parts = append(parts, php.StringLiteral(path[last:]))
+	}
+	return strings.Join(parts, " . ")
+}
@@ -0,0 +1,382 @@
+package templates_test
+
+import (
+	"testing"
+
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/floriscornel/piak/internal/php"
+	"github.com/floriscornel/piak/internal/templates"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClientData_OperationMethods(t *testing.T) {
+	model := &config.InternalModel{Operations: []*config.OperationModel{{
+		OperationID: "getPhoto",
+		MethodName:  "getPhoto",
+		Method:      "GET",
+		Path:        "/pets/{petId}/photos/{photo-id}",
+		Summary:     "Get a photo",
+		Parameters: []*config.ParameterModel{
+			{Name: "petId", In: "path", Required: true, OpenAPIType: openapi3.NewIntegerSchema()},
+			{Name: "photo-id", In: "path", Required: true, OpenAPIType: openapi3.NewStringSchema()},
+		},
+	}}}
+
+	data := templates.NewClientData(model, &config.GeneratorConfig{Namespace: "App"})
+	require.Len(t, data.Methods, 1)
+
+	method := php.PrintMethod(data.Methods[0], 0)
+	assert.Contains(t, method,
+		"public function getPhoto(int $petId, string $photoId, array $data = [], array $headers = []): array")
+	assert.Contains(t, method,
+		"$endpoint = '/pets/' . rawurlencode((string) $petId) . '/photos/' . rawurlencode($photoId);")
+	assert.Contains(t, method, "return $this->request('GET', $endpoint, $data, $headers);")
+	assert.NotContains(t, method, "@deprecated")
+}
+
+func TestNewClientData_Int64PathParameters(t *testing.T) {
+	model := &config.InternalModel{Operations: []*config.OperationModel{{
+		OperationID: "getOrder",
+		MethodName:  "getOrder",
+		Method:      "GET",
+		Path:        "/orders/{orderId}",
+		Parameters: []*config.ParameterModel{
+			{Name: "orderId", In: "path", Required: true, OpenAPIType: openapi3.NewInt64Schema()},
+		},
+	}}}
+
+	data := templates.NewClientData(model, &config.GeneratorConfig{Namespace: "App", Int64: config.Int64String})
+	require.Len(t, data.Methods, 1)
+	assert.Contains(t, php.PrintMethod(data.Methods[0], 0), "public function getOrder(int|string $orderId,")
+
+	data = templates.NewClientData(model, &config.GeneratorConfig{Namespace: "App"})
+	assert.Contains(t, php.PrintMethod(data.Methods[0], 0), "public function getOrder(int $orderId,")
+}
+
+func TestNewClientData_ParameterStyles(t *testing.T) {
+	model := &config.InternalModel{Operations: []*config.OperationModel{{
+		OperationID: "getPets",
+		MethodName:  "getPets",
+		Method:      "GET",
+		Path:        "/pets/{ids}/{name}",
+		Parameters: []*config.ParameterModel{
+			{Name: "ids", In: "path", Style: "matrix", Explode: true, OpenAPIType: openapi3.NewArraySchema()},
+			{Name: "name", In: "path", Style: "simple", OpenAPIType: openapi3.NewStringSchema()},
+			{Name: "tags", In: "query", Style: "pipeDelimited"},
+			{Name: "X-Trace", In: "header", Style: "simple"},
+		},
+	}}}
+
+	method := php.PrintMethod(templates.NewClientData(model, &config.GeneratorConfig{}).Methods[0], 0)
+	assert.Contains(t, method, "@param array<mixed> $ids\n")
+	assert.Contains(t, method, "public function getPets(array $ids, string $name,")
+	assert.Contains(t, method,
+		"$endpoint = '/pets/' . self::serializeParameter('ids', $ids, 'matrix', true) . '/' . rawurlencode($name);")
+	assert.Contains(t, method, `        parameters: [
+            'tags' => ['in' => 'query', 'style' => 'pipeDelimited', 'explode' => false],
+            'X-Trace' => ['in' => 'header', 'style' => 'simple', 'explode' => false],
+        ],`)
+}
+
+func TestNewClientData_RequestBodies(t *testing.T) {
+	model := &config.InternalModel{Operations: []*config.OperationModel{
+		{
+			OperationID: "uploadPhoto",
+			MethodName:  "uploadPhoto",
+			Method:      "POST",
+			Path:        "/photos",
+			RequestBody: &config.RequestBodyModel{
+				MediaType:        config.MediaTypeMultipart,
+				Files:            []string{"photo", "thumbnails"},
+				PartContentTypes: map[string]string{"photo": "image/png", "meta": "application/json"},
+			},
+		},
+		{
+			OperationID: "createPet",
+			MethodName:  "createPet",
+			Method:      "POST",
+			Path:        "/pets",
+			RequestBody: &config.RequestBodyModel{MediaType: config.MediaTypeJSON},
+		},
+		{
+			OperationID: "putFile",
+			MethodName:  "putFile",
+			Method:      "PUT",
+			Path:        "/files/{body}",
+			RequestBody: &config.RequestBodyModel{MediaType: config.MediaTypeOctetStream, Required: true},
+		},
+	}}
+
+	data := templates.NewClientData(model, &config.GeneratorConfig{})
+	method := php.PrintMethod(data.Methods[0], 0)
+	assert.Contains(t, method, "Files are resources, PSR-7 streams,\n *     SplFileInfo objects or paths\n")
+	assert.Contains(t, method, `        contentType: 'multipart/form-data',
+        encoding: [
+            'meta' => ['contentType' => 'application/json'],
+            'photo' => ['contentType' => 'image/png', 'file' => true],
+            'thumbnails' => ['file' => true],
+        ],`)
+	assert.Contains(t, php.PrintMethod(data.Methods[1], 0), "return $this->request('POST', '/pets', $data, $headers);")
+
+	method = php.PrintMethod(data.Methods[2], 0)
+	assert.Contains(t, method, " * @param string|resource|StreamInterface $body The body, sent as is\n")
+	assert.Contains(t, method, "putFile(string $pathBody, mixed $body, array $data = [], array $headers = []): array")
+	assert.Contains(t, method, "$headers, contentType: 'application/octet-stream', body: $body);")
+}
+
+func TestNewClientData_StreamingResponses(t *testing.T) {
+	model := &config.InternalModel{Operations: []*config.OperationModel{
+		{
+			OperationID: "downloadFile",
+			MethodName:  "downloadFile",
+			Method:      "GET",
+			Path:        "/files",
+			Response:    &config.ResponseModel{Kind: config.ResponseBinary, MediaTypes: []string{"image/png"}},
+		},
+		{
+			OperationID: "exportEvents",
+			MethodName:  "exportEvents",
+			Method:      "GET",
+			Path:        "/events",
+			Response: &config.ResponseModel{
+				Kind: config.ResponseNDJSON, MediaTypes: []string{"application/x-ndjson"},
+				ItemClass: "Event", ItemDataType: "EventData",
+			},
+		},
+		{
+			OperationID: "streamEvents",
+			MethodName:  "streamEvents",
+			Method:      "GET",
+			Path:        "/events/stream",
+			Response: &config.ResponseModel{
+				Kind: config.ResponseEvents, MediaTypes: []string{"text/event-stream"},
+				ItemClass: "Event", ItemDataType: "EventData", JSONEvents: true,
+			},
+		},
+	}}
+
+	data := templates.NewClientData(model, &config.GeneratorConfig{})
+	assert.Equal(t, []string{"EventData from Event"}, data.TypeImports)
+
+	method := php.PrintMethod(data.Methods[0], 0)
+	assert.Contains(t, method, "array $headers = [], mixed $sink = null): StreamInterface")
+	assert.Contains(t, method,
+		"return $this->requestStream('GET', '/files', $data, $headers + ['Accept' => 'image/png'], sink: $sink);")
+
+	method = php.PrintMethod(data.Methods[1], 0)
+	assert.Contains(t, method, "@return \\Generator<int, Event>\n")
+	assert.Contains(t, method,
+		"$lines = $this->requestLines('GET', '/events', $data, $headers + ['Accept' => 'application/x-ndjson']);")
+	assert.Contains(t, method, "    /** @var EventData $line */\n        yield Event::fromArray($line);")
+
+	method = php.PrintMethod(data.Methods[2], 0)
+	assert.Contains(t, method, "array $headers = [], ?string $lastEventId = null): \\Generator")
+	assert.Contains(t, method, "@return \\Generator<int, ServerSentEvent<Event>>\n")
+	assert.Contains(t, method, "        json: true,\n        lastEventId: $lastEventId,\n    );\n")
+	assert.Contains(t, method, "    yield $event->withData(Event::fromArray($eventData));")
+}
+
+func TestNewClientData_DeprecatedOperations(t *testing.T) {
+	model := &config.InternalModel{Operations: []*config.OperationModel{{
+		OperationID: "listPets",
+		MethodName:  "listPets",
+		Method:      "GET",
+		Path:        "/pets",
+		Deprecated:  true,
+		Parameters:  []*config.ParameterModel{{Name: "size", In: "query", Deprecated: true}},
+	}}}
+
+	method := php.PrintMethod(templates.NewClientData(model, &config.GeneratorConfig{}).Methods[0], 0)
+	assert.Contains(t, method, "Deprecated parameters: size (query).")
+	assert.Contains(t, method, "@deprecated")
+	assert.NotContains(t, method, "trigger_error")
+	assert.Contains(t, method, `    return $this->request(
+        'GET',
+        '/pets',
+        $data,
+        $headers,
+        parameters: [
+            'size' => ['in' => 'query', 'style' => 'form', 'explode' => true],
+        ],
+    );`)
+
+	cfg := &config.GeneratorConfig{DeprecationNotices: true}
+	method = php.PrintMethod(templates.NewClientData(model, cfg).Methods[0], 0)
+	assert.Contains(t, method, "trigger_error('Operation listPets (GET /pets) is deprecated', E_USER_DEPRECATED);")
+
+	// The attribute makes PHP raise the notice itself
+	cfg.DeprecatedAttributes = true
+	method = php.PrintMethod(templates.NewClientData(model, cfg).Methods[0], 0)
+	assert.Contains(t, method, "#[\\Deprecated]\npublic function listPets(")
+	assert.NotContains(t, method, "trigger_error")
+}
+
+func TestNewClientData_Security(t *testing.T) {
+	model := &config.InternalModel{
+		Operations: []*config.OperationModel{{
+			OperationID: "listPets",
+			MethodName:  "listPets",
+			Method:      "GET",
+			Path:        "/pets",
+			Security:    []map[string][]string{{"oauth": {"read"}}, {}},
+		}},
+		SecuritySchemes: []*config.SecuritySchemeModel{
+			{Name: "key", Kind: config.SecurityAPIKey, In: "header", ParamName: "X-Key", SetterName: "setKey"},
+			{Name: "oauth", Kind: config.SecurityOAuth2, TokenURL: "/token", SetterName: "setOauth"},
+		},
+	}
+
+	data := templates.NewClientData(model, &config.GeneratorConfig{})
+	assert.True(t, data.OAuth2)
+	require.Len(t, data.CredentialSetters, 2)
+
+	setter := php.PrintMethod(data.CredentialSetters[0], 0)
+	assert.Contains(t, setter, "sent in the X-Key header")
+	assert.Contains(t, setter, "public function setKey(string $apiKey): static")
+	assert.Contains(t, setter, "$this->credentials['key'] = ['apiKey' => $apiKey];")
+
+	setter = php.PrintMethod(data.CredentialSetters[1], 0)
+	assert.Contains(t, setter, "public function setOauth(string $clientId, string $clientSecret): static")
+	assert.Contains(t, setter, "unset($this->accessTokens['oauth']);")
+
+	assert.Contains(t, php.PrintMethod(data.Methods[0], 0),
+		"return $this->request('GET', '/pets', $data, $headers, [['oauth' => ['read']], []]);")
+}
+
+func TestNewClientData_ErrorResponses(t *testing.T) {
+	model := &config.InternalModel{Operations: []*config.OperationModel{{
+		OperationID: "getPet",
+		MethodName:  "getPet",
+		Method:      "GET",
+		Path:        "/pets",
+		ErrorResponses: []*config.ErrorResponseModel{
+			{Status: "404", Description: "Pet not found", Exception: "NotFoundException"},
+			{Status: "4XX", Exception: "ClientErrorException", ClassName: "Error", DataType: "ErrorData"},
+			{Status: "default", Description: "Unexpected", Exception: "ApiException", ClassName: "Error", DataType: "ErrorData"},
+		},
+	}}}
+
+	data := templates.NewClientData(model, &config.GeneratorConfig{})
+	assert.Equal(t, []string{"ErrorData from Error"}, data.TypeImports)
+
+	method := php.PrintMethod(data.Methods[0], 0)
+	assert.Contains(t, method, "@throws NotFoundException 404: Pet not found\n")
+	assert.Contains(t, method, "@throws ClientErrorException 4XX\n")
+	assert.Contains(t, method, "@throws ApiException Other statuses: Unexpected\n")
+	assert.Contains(t, method, `        $headers,
+        errors: [
+            '4XX' => static function (array $error): Error {
+                /** @var ErrorData $error */
+                return Error::fromArray($error);
+            },
+            'default' => static function (array $error): Error {`)
+}
+
+func TestNewClientData_Retryable(t *testing.T) {
+	retryable := true
+	model := &config.InternalModel{Operations: []*config.OperationModel{
+		{OperationID: "createJob", MethodName: "createJob", Method: "POST", Path: "/jobs", Retryable: &retryable},
+		{OperationID: "listJobs", MethodName: "listJobs", Method: "GET", Path: "/jobs"},
+	}}
+
+	data := templates.NewClientData(model, &config.GeneratorConfig{})
+	assert.Contains(t, php.PrintMethod(data.Methods[0], 0),
+		"return $this->request('POST', '/jobs', $data, $headers, retryable: true);")
+	assert.Contains(t, php.PrintMethod(data.Methods[1], 0), "return $this->request('GET', '/jobs', $data, $headers);")
+}
+
+func TestNewClientData_Iterators(t *testing.T) {
+	model := &config.InternalModel{Operations: []*config.OperationModel{
+		{
+			OperationID: "listPets",
+			MethodName:  "listPets",
+			Method:      "GET",
+			Path:        "/pets",
+			Pagination: &config.PaginationModel{
+				Style: config.PaginationCursor, Items: "data", CursorParam: "cursor", NextCursor: "next_cursor",
+				IteratorName: "listPetsIterator", ItemClass: "Pet", ItemDataType: "PetData",
+			},
+		},
+		{
+			OperationID: "listTags",
+			MethodName:  "listTags",
+			Method:      "GET",
+			Path:        "/tags",
+			Pagination:  &config.PaginationModel{Style: config.PaginationLink, IteratorName: "listTagsIterator"},
+		},
+	}}
+
+	data := templates.NewClientData(model, &config.GeneratorConfig{})
+	assert.True(t, data.Pagination)
+	assert.Equal(t, []string{"PetData from Pet"}, data.TypeImports)
+	require.Len(t, data.Methods, 4)
+
+	iterator := php.PrintMethod(data.Methods[1], 0)
+	assert.Contains(t, iterator, "@return \\Generator<int, Pet>\n")
+	assert.Contains(t, iterator, "public function listPetsIterator(array $data = [], array $headers = []): \\Generator")
+	assert.Contains(t, iterator, `    $items = $this->paginate(
+        ['style' => 'cursor', 'items' => 'data', 'cursorParam' => 'cursor', 'nextCursor' => 'next_cursor'],
+        'GET',
+        '/pets',
+        $data,
+        $headers,
+    );
+    foreach ($items as $item) {
+        /** @var PetData $item */
+        yield Pet::fromArray($item);
+    }`)
+
+	iterator = php.PrintMethod(data.Methods[3], 0)
+	assert.Contains(t, iterator, "@return \\Generator<int, mixed>\n")
+	assert.Contains(t, iterator,
+		"yield from $this->paginate(['style' => 'link', 'items' => ''], 'GET', '/tags', $data, $headers);")
+}
+
+func TestNewClientData_Servers(t *testing.T) {
+	production := &config.ServerModel{
+		Name:            "production",
+		URL:             "https://{region}.api.example.com/v1/",
+		ConstructorName: "production",
+		Variables: []*config.ServerVariableModel{
+			{Name: "region", Default: "eu", Enum: []string{"eu", "us"}, ParamName: "region"},
+		},
+	}
+	model := &config.InternalModel{
+		Servers: []*config.ServerModel{production},
+		Operations: []*config.OperationModel{{
+			OperationID: "uploadFile",
+			MethodName:  "uploadFile",
+			Method:      "PUT",
+			Path:        "/files",
+			Servers:     []*config.ServerModel{{Name: "uploads", URL: "https://uploads.example.com"}},
+		}},
+	}
+
+	data := templates.NewClientData(model, &config.GeneratorConfig{})
+	assert.Equal(t, "https://eu.api.example.com/v1", data.DefaultServerURL)
+	assert.Contains(t, php.PrintStmt(data.ServerList, 0),
+		"    'production' => [\n        'url' => 'https://{region}.api.example.com/v1/',\n        'variables' => [\n"+
+			"            'region' => ['default' => 'eu', 'enum' => ['eu', 'us']],\n")
+	assert.Contains(t, php.PrintStmt(data.OperationServerList, 0), "'uploadFile' => [\n        'uploads' => [\n")
+
+	require.Len(t, data.ServerConstructors, 1)
+	constructor := php.PrintMethod(data.ServerConstructors[0], 0)
+	assert.Contains(t, constructor, "@param 'eu'|'us' $region\n")
+	assert.Contains(t, constructor, "public static function production(\n    string $region = 'eu',\n")
+	assert.Contains(t, constructor, "return self::forServer(\n        'production',\n        ['region' => $region],\n")
+
+	assert.Contains(t, php.PrintMethod(data.Methods[0], 0),
+		"return $this->request('PUT', $this->operationServer('uploadFile') . '/files', $data, $headers);")
+}
+
+func TestExceptionClass(t *testing.T) {
+	assert.Equal(t, "NotFoundException", templates.ExceptionClass("404"))
+	assert.Equal(t, "ValidationErrorException", templates.ExceptionClass("422"))
+	assert.Equal(t, "ClientErrorException", templates.ExceptionClass("418"))
+	assert.Equal(t, "ClientErrorException", templates.ExceptionClass("4XX"))
+	assert.Equal(t, "ServerErrorException", templates.ExceptionClass("503"))
+	assert.Equal(t, "ServerErrorException", templates.ExceptionClass("5XX"))
+	assert.Equal(t, "ApiException", templates.ExceptionClass("default"))
+}
@@ -0,0 +1,118 @@
+package templates
+
+import (
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/floriscornel/piak/internal/php"
+)
+
+// Template data contract
+//
+// These types are the data passed to each top-level template. They are part of the
+// public contract for user template directories (see docs/templates.md): fields may
+// be added, but existing fields are not renamed or removed without a major release.
+
+// ModelData is passed to model.php.tmpl and the class partials it includes.
+// Class holds the members built for the model and Uses the imports they need.
+// DocTags are extra class docblock tags without the leading @, such as the
+// phpstan-type alias describing the model's array data.
+type ModelData struct {
+	*config.SchemaModel
+	Config  *config.GeneratorConfig
+	Class   *php.Class
+	Uses    []php.Use
+	DocTags []string
+}
+
+// EnumData is passed to enum.php.tmpl, which also uses the classDocblock partial.
+// Enum holds the cases built for the schema. DocTags is as in ModelData.
+type EnumData struct {
+	*config.SchemaModel
+	Config  *config.GeneratorConfig
+	Enum    *php.Enum
+	DocTags []string
+}
+
+// ClientData is passed to client.php.tmpl. Methods holds a method per operation,
+// followed by its iterator for paginated ones, and CredentialSetters a method per
+// security scheme, setting its credentials. OAuth2 is set when a scheme uses OAuth2
+// client credentials, and Pagination when an operation is paginated. TypeImports are
+// the @phpstan-import-type tags of the error and item models the methods hydrate,
+// such as "ErrorData from Error". ServerList and OperationServerList declare the
+// constants of the servers of the spec and of the operations, if any, and
+// ServerConstructors holds a named constructor per server of the spec.
+// DefaultServerURL is the URL of the first of them, with the defaults of its
+// variables, which the client is created for when given no base URL.
+type ClientData struct {
+	*config.InternalModel
+	Config            *config.GeneratorConfig
+	Methods           []*php.Method
+	CredentialSetters []*php.Method
+	OAuth2            bool
+	Pagination        bool
+	TypeImports       []string
+
+	ServerList          php.Stmt
+	OperationServerList php.Stmt
+	ServerConstructors  []*php.Method
+	DefaultServerURL    string
+}
+
+// ComposerData is passed to composer.json.tmpl. Values are raw text; templates
+// escape them with toJSON. JSONNamespace is kept for existing custom templates.
+// Require lists the packages the generated code needs, by name, with their
+// version constraints. GenerateClient is set when the API client is generated.
+type ComposerData struct {
+	PackageName    string
+	Description    string
+	Namespace      string
+	JSONNamespace  string
+	Require        map[string]string
+	GenerateClient bool
+}
+
+// SupportClassData is passed to the templates of classes generated next to the
+// models, such as undefined.php.tmpl.
+type SupportClassData struct {
+	Namespace string
+	ClassName string
+}
+
+// ExceptionData is passed to the templates of the exceptions the client throws for
+// error responses: api-exception.php.tmpl, which gets StatusExceptions, and
+// status-exception.php.tmpl, which gets the Parent class and a Summary.
+type ExceptionData struct {
+	Namespace        string
+	ClassName        string
+	Parent           string
+	Summary          string
+	StatusExceptions []StatusException
+}
+
+// ModelTestData is passed to model-test.php.tmpl.
+type ModelTestData struct {
+	ClassName     string
+	VarName       string
+	TestNamespace string
+	UseNamespace  string
+	SpecFilename  string
+	Schema        *config.SchemaModel
+}
+
+// ClientTestData is passed to client-test.php.tmpl. Pagination is set when an
+// operation is paginated, the client then having paginate(), and Servers when the
+// spec declares servers, the client then having forServer().
+type ClientTestData struct {
+	TestNamespace string
+	UseNamespace  string
+	SpecFilename  string
+	Pagination    bool
+	Servers       bool
+}
+
+// ReadmeData is passed to README.md.tmpl.
+type ReadmeData struct {
+	PackageName    string
+	Namespace      string
+	SpecFilename   string
+	GenerateClient bool
+}
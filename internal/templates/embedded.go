@@ -2,9 +2,14 @@ package templates
 
 import (
 	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"strings"
 	"text/template"
 
+	"github.com/floriscornel/piak/internal/php"
 	"github.com/iancoleman/strcase"
 	"github.com/jinzhu/inflection"
 )
@@ -12,9 +17,61 @@ import (
 //go:embed *.tmpl partials/**/*.tmpl
 var templateFS embed.FS
 
-// GetTemplates returns all embedded templates with custom functions.
-func GetTemplates() (*template.Template, error) {
-	funcMap := template.FuncMap{
+// templateExt is the file extension of every template file.
+const templateExt = ".tmpl"
+
+// GetTemplates returns all embedded templates with custom functions. Each template is
+// named by its path relative to the template root, such as model.php.tmpl or
+// partials/docblocks/class.tmpl.
+//
+// When overrideDir is not empty, every .tmpl file found in it (recursively) is parsed
+// after the embedded set. A file at the same relative path as an embedded template
+// replaces it, and any {{define}} block redefines the embedded partial of the same
+// name. Files matching no embedded template are rejected, as they would be ignored.
+func GetTemplates(overrideDir string) (*template.Template, error) {
+	tmpl := template.New("").Funcs(funcMap())
+
+	paths, err := embeddedPaths()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		content, readErr := templateFS.ReadFile(path)
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read embedded template %s: %w", path, readErr)
+		}
+		if _, parseErr := tmpl.New(path).Parse(string(content)); parseErr != nil {
+			return nil, fmt.Errorf("failed to parse embedded template %s: %w", path, parseErr)
+		}
+	}
+
+	if overrideDir != "" {
+		if overrideErr := parseOverrides(tmpl, overrideDir, paths); overrideErr != nil {
+			return nil, overrideErr
+		}
+	}
+
+	return tmpl, nil
+}
+
+// embeddedPaths returns the slash-separated paths of the embedded templates, sorted.
+func embeddedPaths() ([]string, error) {
+	var paths []string
+	err := fs.WalkDir(templateFS, ".", func(path string, entry fs.DirEntry, walkErr error) error {
+		if walkErr == nil && !entry.IsDir() {
+			paths = append(paths, path)
+		}
+		return walkErr
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list embedded templates: %w", err)
+	}
+	return paths, nil
+}
+
+// funcMap returns the functions available to every template.
+func funcMap() template.FuncMap {
+	return template.FuncMap{
 		// Basic string functions
 		"toCamel":          strcase.ToCamel,
 		"toSnake":          strcase.ToSnake,
@@ -31,10 +88,24 @@ func GetTemplates() (*template.Template, error) {
 		"sub":         func(a, b int) int { return a - b },
 		"add":         func(a, b int) int { return a + b },
 
+		// Context-aware escaping of spec-derived text
+		"phpString":     php.StringLiteral,
+		"phpDoc":        php.DocComment,
+		"markdownText":  php.MarkdownText,
+		"phpIdentifier": php.Identifier,
+		"toJSON":        php.JSONString,
+
 		// PHP-specific type formatting
-		"formatPHPType":         formatPHPType,
-		"renderFromArrayMethod": renderFromArrayMethod,
-		"renderToArrayMethod":   renderToArrayMethod,
+		"formatPHPType":            formatPHPType,
+		"renderConstructor":        renderConstructor,
+		"renderFromArrayMethod":    renderFromArrayMethod,
+		"renderToArrayMethod":      renderToArrayMethod,
+		"renderValidateMethod":     renderValidateMethod,
+		"renderEnumCases":          renderEnumCases,
+		"renderClientMethods":      renderClientMethods,
+		"renderCredentialSetters":  renderCredentialSetters,
+		"renderServerConstructors": renderServerConstructors,
+		"renderStmt":               renderStmt,
 
 		// Test data generation helpers
 		"generateTestData":                generateTestData,
@@ -42,15 +113,96 @@ func GetTemplates() (*template.Template, error) {
 		"generateAssertions":              generateAssertions,
 		"generateSerializationAssertions": generateSerializationAssertions,
 		"generateMinimalTestData":         generateMinimalTestData,
+		"generateDefaultAssertions":       generateDefaultAssertions,
+		"referencedClasses":               referencedClasses,
+		"untestableProperty":              untestableProperty,
+	}
+}
+
+// parseOverrides parses every template file in dir on top of the embedded set, whose
+// paths are given.
+func parseOverrides(tmpl *template.Template, dir string, embedded []string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read template directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("template directory is not a directory: %s", dir)
+	}
+
+	known := make(map[string]bool, len(embedded))
+	for _, path := range embedded {
+		known[path] = true
 	}
 
-	tmpl := template.New("").Funcs(funcMap)
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if entry.IsDir() || filepath.Ext(path) != templateExt {
+			return nil
+		}
+
+		rel, relErr := filepath.Rel(dir, path)
+		if relErr != nil {
+			return fmt.Errorf("failed to resolve template %s: %w", path, relErr)
+		}
+		name := filepath.ToSlash(rel)
+		if !known[name] {
+			return fmt.Errorf("template %s overrides no built-in template, "+
+				"see 'piak templates export' for their paths", path)
+		}
+
+		content, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return fmt.Errorf("failed to read template %s: %w", path, readErr)
+		}
+
+		// Parsing under the name of the embedded template replaces it
+		if _, parseErr := tmpl.New(name).Parse(string(content)); parseErr != nil {
+			return fmt.Errorf("failed to parse template %s: %w", path, parseErr)
+		}
+		return nil
+	})
+}
 
-	// Try to parse templates, return empty template if none exist
-	parsed, err := tmpl.ParseFS(templateFS, "*.tmpl", "partials/**/*.tmpl")
+// Export writes the embedded templates to dir, keeping their directory layout so the
+// result can be used as a template directory right away. Existing files are only
+// replaced when overwrite is true. It returns the paths of the written files.
+func Export(dir string, overwrite bool) ([]string, error) {
+	sources, err := embeddedPaths()
 	if err != nil {
-		// Return just the base template with functions if no .tmpl files exist
-		return tmpl, err
+		return nil, err
 	}
-	return parsed, nil
+
+	// Check for conflicts up front so a refused export writes nothing
+	if !overwrite {
+		for _, path := range sources {
+			target := filepath.Join(dir, filepath.FromSlash(path))
+			if _, statErr := os.Stat(target); statErr == nil {
+				return nil, fmt.Errorf("file already exists: %s", target)
+			}
+		}
+	}
+
+	written := make([]string, 0, len(sources))
+	for _, path := range sources {
+		target := filepath.Join(dir, filepath.FromSlash(path))
+
+		content, readErr := templateFS.ReadFile(path)
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read embedded template %s: %w", path, readErr)
+		}
+
+		if mkdirErr := os.MkdirAll(filepath.Dir(target), 0755); mkdirErr != nil {
+			return nil, fmt.Errorf("failed to create directory for %s: %w", target, mkdirErr)
+		}
+		if writeErr := os.WriteFile(target, content, 0644); writeErr != nil {
+			return nil, fmt.Errorf("failed to write template %s: %w", target, writeErr)
+		}
+
+		written = append(written, target)
+	}
+
+	return written, nil
 }
@@ -0,0 +1,119 @@
+package templates_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/floriscornel/piak/internal/templates"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTemplates_Embedded(t *testing.T) {
+	tmpl, err := templates.GetTemplates("")
+	require.NoError(t, err)
+
+	for _, name := range []string{"model.php.tmpl", "client.php.tmpl", "classHeader", "constructorSignature"} {
+		assert.NotNil(t, tmpl.Lookup(name), "template %s should be defined", name)
+	}
+}
+
+func TestGetTemplates_OverridesFilesAndPartials(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "composer.json.tmpl"),
+		[]byte(`{"name": "{{ .PackageName }}", "license": "proprietary"}`), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "partials", "docblocks"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "partials", "docblocks", "class.tmpl"),
+		[]byte(`{{- define "classDocblock" }}
+/** Custom docblock for {{ .Name }} */
+{{- end -}}`), 0644))
+
+	tmpl, err := templates.GetTemplates(dir)
+	require.NoError(t, err)
+
+	var composer strings.Builder
+	require.NoError(t, tmpl.ExecuteTemplate(&composer, "composer.json.tmpl", templates.ComposerData{PackageName: "acme/api"}))
+	assert.JSONEq(t, `{"name": "acme/api", "license": "proprietary"}`, composer.String())
+
+	var model strings.Builder
+	err = tmpl.ExecuteTemplate(&model, "model.php.tmpl", templates.NewModelData(
+		&config.SchemaModel{Name: "Pet"},
+		&config.GeneratorConfig{Namespace: "App"},
+	))
+	require.NoError(t, err)
+	assert.Contains(t, model.String(), "/** Custom docblock for Pet */")
+}
+
+func TestGetTemplates_InvalidOverride(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "model.php.tmpl"), []byte("{{ .Name "), 0644))
+
+	_, err := templates.GetTemplates(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse template")
+
+	_, err = templates.GetTemplates(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+
+	// Overrides are matched by their path, so a partial outside its directory would be ignored
+	dir = t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "class.tmpl"),
+		[]byte(`{{ define "classDocblock" }}{{ end }}`), 0644))
+	_, err = templates.GetTemplates(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "class.tmpl overrides no built-in template")
+}
+
+func TestGetTemplates_SchemaModelRenderFunctions(t *testing.T) {
+	tmpl, err := templates.GetTemplates("")
+	require.NoError(t, err)
+	tmpl, err = tmpl.New("legacy").Parse("{{ renderFromArrayMethod .SchemaModel }}")
+	require.NoError(t, err)
+
+	// Templates written before ModelData had Class pass the schema and print no use statements
+	price := templates.NewModelData(&config.SchemaModel{Name: "Price", Properties: []*config.Property{
+		{Name: "amount", Required: true, PHPType: config.PHPType{
+			Name: `\Brick\Math\BigDecimal`, Numeric: config.NumericBigDecimal,
+		}},
+	}}, &config.GeneratorConfig{Namespace: "App"})
+	var out strings.Builder
+	require.NoError(t, tmpl.Execute(&out, price))
+	assert.Contains(t, out.String(), "    public static function fromArray(array $data")
+	assert.Contains(t, out.String(), `\Brick\Math\BigDecimal::of(`)
+	assert.Contains(t, out.String(), "throw new ValidationException(")
+
+	tmpl, err = tmpl.New("enum").Parse("{{ renderToArrayMethod . }}")
+	require.NoError(t, err)
+	require.Error(t, tmpl.Execute(&out, templates.EnumData{}))
+}
+
+func TestGenerateTestData_Cycles(t *testing.T) {
+	tmpl, err := templates.GetTemplates("")
+	require.NoError(t, err)
+	tmpl, err = tmpl.New("data").Parse("{{ generateTestData . }}")
+	require.NoError(t, err)
+
+	// A node with an optional parent node and child nodes
+	node := &config.SchemaModel{Name: "Node", Recursive: true}
+	nodeType := config.PHPType{Name: "Node", Model: node}
+	node.Properties = []*config.Property{
+		{Name: "label", PHPType: config.PHPType{Name: "string"}},
+		{Name: "parent", PHPType: nodeType},
+		{Name: "children", PHPType: config.PHPType{Name: "array", IsArray: true, Items: &nodeType}},
+	}
+	root := &config.SchemaModel{Name: "Tree", Properties: []*config.Property{
+		{Name: "root", PHPType: nodeType, Required: true},
+	}}
+
+	var data strings.Builder
+	require.NoError(t, tmpl.Execute(&data, root))
+	assert.Contains(t, data.String(), "'root' => ['label' => 'test_label']")
+
+	data.Reset()
+	require.NoError(t, tmpl.Execute(&data, node))
+	assert.Contains(t, data.String(), "'children' => []")
+	assert.NotContains(t, data.String(), "'parent'")
+}
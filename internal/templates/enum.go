@@ -0,0 +1,47 @@
+package templates
+
+import (
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/floriscornel/piak/internal/php"
+)
+
+// NewEnumData builds the template data for an enum schema, including the PHP enum
+// whose cases enum.php.tmpl prints.
+func NewEnumData(schema *config.SchemaModel, cfg *config.GeneratorConfig) EnumData {
+	enum := &php.Enum{
+		Name:        schema.Name,
+		BackingType: EnumBackingType(schema.EnumCases),
+	}
+	for _, enumCase := range schema.EnumCases {
+		// Case values are strings or ints, which Literal always accepts
+		value, _ := php.Literal(enumCase.Value)
+		phpCase := &php.EnumCase{Name: enumCase.Name, Value: value}
+		if enumCase.Deprecated {
+			phpCase.Doc = php.NewDocBlock().Tag("deprecated", "")
+			if cfg.DeprecatedAttributes {
+				phpCase.Attributes = []string{`\Deprecated`}
+			}
+		}
+		enum.Cases = append(enum.Cases, phpCase)
+	}
+
+	return EnumData{
+		SchemaModel: schema,
+		Config:      cfg,
+		Enum:        enum,
+	}
+}
+
+// EnumBackingType returns the backing type of an enum with the given cases: int
+// when every value is an int, string otherwise.
+func EnumBackingType(cases []*config.EnumCase) string {
+	for _, enumCase := range cases {
+		if _, ok := enumCase.Value.(int); !ok {
+			return "string"
+		}
+	}
+	if len(cases) == 0 {
+		return "string"
+	}
+	return "int"
+}
@@ -0,0 +1,52 @@
+package templates
+
+import "strconv"
+
+// Classes of the exceptions the client throws for error responses. Specific status
+// codes have exceptions of their own extending ClientErrorException, see
+// StatusExceptions.
+const (
+	APIExceptionClass         = "ApiException"
+	ClientErrorExceptionClass = "ClientErrorException"
+	ServerErrorExceptionClass = "ServerErrorException"
+	ProblemDetailsClass       = "ProblemDetails"
+)
+
+// StatusException is the exception thrown for a specific status code.
+type StatusException struct {
+	Status    int
+	Reason    string
+	ClassName string
+}
+
+// StatusExceptions are the exceptions of specific status codes.
+var StatusExceptions = []StatusException{
+	{400, "Bad Request", "BadRequestException"},
+	{401, "Unauthorized", "UnauthorizedException"},
+	{403, "Forbidden", "ForbiddenException"},
+	{404, "Not Found", "NotFoundException"},
+	{409, "Conflict", "ConflictException"},
+	{422, "Unprocessable Content", "ValidationErrorException"},
+	{429, "Too Many Requests", "TooManyRequestsException"},
+}
+
+// ExceptionClass returns the class of the exception thrown for a response status
+// of the spec: a status code, a range such as "4XX" or "default".
+func ExceptionClass(status string) string {
+	code, err := strconv.Atoi(status)
+	if err == nil {
+		for _, exception := range StatusExceptions {
+			if exception.Status == code {
+				return exception.ClassName
+			}
+		}
+	}
+	switch {
+	case status == "default":
+		return APIExceptionClass
+	case status[0] == '5':
+		return ServerErrorExceptionClass
+	default:
+		return ClientErrorExceptionClass
+	}
+}
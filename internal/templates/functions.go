@@ -2,9 +2,11 @@ package templates
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/floriscornel/piak/internal/config"
+	"github.com/floriscornel/piak/internal/php"
 )
 
 // PHP-specific template helper functions
@@ -20,90 +22,99 @@ func formatPHPType(phpType config.PHPType) string {
 		typeStr = phpType.Name
 	}
 
-	if phpType.IsNullable && !strings.Contains(typeStr, "null") {
+	// mixed already includes null and cannot be made nullable
+	switch {
+	case !phpType.IsNullable || typeStr == "mixed" || strings.Contains(typeStr, "null"):
+	case strings.Contains(typeStr, "|"):
+		// Union types take null as another member
+		typeStr += "|null"
+	default:
 		typeStr = "?" + typeStr
 	}
 
 	return typeStr
 }
 
-// renderFromArrayMethod generates a fromArray method for models.
-func renderFromArrayMethod(model *config.SchemaModel) string {
-	var result strings.Builder
-
-	result.WriteString("/**\n")
-	result.WriteString(" * Create instance from array data\n")
-	result.WriteString(" * @param array<string, mixed> $data\n")
-	result.WriteString(" * @return self\n")
-	result.WriteString(" */\n")
-	result.WriteString("public static function fromArray(array $data): self\n")
-	result.WriteString("{\n")
+// renderConstructor prints the model's constructor inside the class body.
+func renderConstructor(data any) (string, error) {
+	return renderModelMethod(data, "__construct")
+}
 
-	// Generate validation for required fields
-	for _, prop := range model.Properties {
-		if prop.Required {
-			result.WriteString(fmt.Sprintf("    if (!isset($data['%s'])) {\n", prop.Name))
-			errMsg := fmt.Sprintf(
-				"        throw new \\InvalidArgumentException('Missing required field: %s');\n",
-				prop.Name,
-			)
-			result.WriteString(errMsg)
-			result.WriteString("    }\n")
-		}
-	}
+// renderFromArrayMethod prints the model's fromArray method inside the class body.
+func renderFromArrayMethod(data any) (string, error) {
+	return renderModelMethod(data, "fromArray")
+}
 
-	result.WriteString("\n    return new self(\n")
+// renderToArrayMethod prints the model's toArray method inside the class body.
+func renderToArrayMethod(data any) (string, error) {
+	return renderModelMethod(data, "toArray")
+}
 
-	// Order parameters to match constructor: required first, then optional
-	var requiredProps []*config.Property
-	var optionalProps []*config.Property
+// renderValidateMethod prints the model's validate method inside the class body, or
+// nothing when the model has no constraints.
+func renderValidateMethod(data any) (string, error) {
+	return renderModelMethod(data, "validate")
+}
 
-	for _, prop := range model.Properties {
-		if prop.Required {
-			requiredProps = append(requiredProps, prop)
-		} else {
-			optionalProps = append(optionalProps, prop)
-		}
+// renderModelMethod prints a method of the model's class, or nothing when it has no
+// such method. Besides ModelData, it takes the *config.SchemaModel earlier templates
+// passed as .SchemaModel. Those templates print no use statements, so the class
+// built for them refers to classes outside the namespace by their qualified names.
+func renderModelMethod(data any, name string) (string, error) {
+	var class *php.Class
+	switch data := data.(type) {
+	case ModelData:
+		class = data.Class
+	case *config.SchemaModel:
+		class = buildModelClass(data, &config.GeneratorConfig{}, php.NewQualifiedImports())
+	default:
+		return "", fmt.Errorf("expected ModelData or *config.SchemaModel, got %T", data)
 	}
 
-	// Combine required and optional properties in correct order
-	requiredProps = append(requiredProps, optionalProps...)
-	allProps := requiredProps
-
-	for i, prop := range allProps {
-		propAccess := fmt.Sprintf("$data['%s'] ?? null", prop.Name)
-		if i < len(allProps)-1 {
-			result.WriteString(fmt.Sprintf("        %s,\n", propAccess))
-		} else {
-			result.WriteString(fmt.Sprintf("        %s\n", propAccess))
-		}
+	if method := class.Method(name); method != nil {
+		return php.PrintMethod(method, 1), nil
 	}
-	result.WriteString("    );\n")
-	result.WriteString("}\n")
-
-	return result.String()
+	return "", nil
 }
 
-// renderToArrayMethod generates a toArray method for models.
-func renderToArrayMethod(model *config.SchemaModel) string {
-	var result strings.Builder
+// renderClientMethods prints the operation methods inside the client class body.
+func renderClientMethods(data ClientData) string {
+	methods := make([]string, 0, len(data.Methods))
+	for _, method := range data.Methods {
+		methods = append(methods, php.PrintMethod(method, 1))
+	}
+	return strings.Join(methods, "\n\n")
+}
 
-	result.WriteString("\n/**\n")
-	result.WriteString(" * Convert instance to array\n")
-	result.WriteString(" * @return array<string, mixed>\n")
-	result.WriteString(" */\n")
-	result.WriteString("public function toArray(): array\n")
-	result.WriteString("{\n")
-	result.WriteString("    return [\n")
+// renderCredentialSetters prints the security scheme credential setters inside the
+// client class body.
+func renderCredentialSetters(data ClientData) string {
+	methods := make([]string, 0, len(data.CredentialSetters))
+	for _, method := range data.CredentialSetters {
+		methods = append(methods, php.PrintMethod(method, 1))
+	}
+	return strings.Join(methods, "\n\n")
+}
 
-	for _, prop := range model.Properties {
-		result.WriteString(fmt.Sprintf("        '%s' => $this->%s,\n", prop.Name, prop.Name))
+// renderServerConstructors prints the named constructors of the servers inside the
+// client class body.
+func renderServerConstructors(data ClientData) string {
+	methods := make([]string, 0, len(data.ServerConstructors))
+	for _, method := range data.ServerConstructors {
+		methods = append(methods, php.PrintMethod(method, 1))
 	}
+	return strings.Join(methods, "\n\n")
+}
 
-	result.WriteString("    ];\n")
-	result.WriteString("}\n")
+// renderStmt prints a statement, such as the declaration of a constant, inside a
+// class body.
+func renderStmt(stmt php.Stmt) string {
+	return php.PrintStmt(stmt, 1)
+}
 
-	return result.String()
+// renderEnumCases prints the enum's cases inside the enum body.
+func renderEnumCases(data EnumData) string {
+	return php.PrintEnumCases(data.Enum, 1)
 }
 
 // Test data generation template helpers
@@ -112,30 +123,76 @@ func renderToArrayMethod(model *config.SchemaModel) string {
 func generateTestData(schema *config.SchemaModel) string {
 	var properties []string
 
-	for _, prop := range schema.Properties {
-		value := generatePropertyTestValue(prop)
-		properties = append(properties, fmt.Sprintf("'%s' => %s", prop.Name, value))
+	path := visiting{}.with(schema)
+	for _, prop := range testPropertiesOf(schema, path) {
+		value := propertyTestValue(prop, path)
+		properties = append(properties, fmt.Sprintf("%s => %s", php.StringLiteral(prop.Name), value))
 	}
 
 	return fmt.Sprintf("[\n        %s\n    ]", strings.Join(properties, ",\n        "))
 }
 
-// generatePropertyTestValue creates a test value for a property.
+// generatePropertyTestValue creates a test value for a property, null when no valid
+// value can be built.
 func generatePropertyTestValue(prop *config.Property) string {
+	if value := propertyTestValue(prop, visiting{}); value != "" {
+		return value
+	}
+	return "null"
+}
+
+// propertyTestValue creates a test value for a property, with nested data for
+// properties typed with a model class. It returns "" when no valid value can be
+// built for a property that is not nullable.
+func propertyTestValue(prop *config.Property, path visiting) string {
+	// Enums only accept one of their values
+	if len(prop.EnumValues) > 0 {
+		if value, err := php.Literal(prop.EnumValues[0]); err == nil {
+			return value
+		}
+	}
+
+	// Examples from the spec are the most likely to satisfy patterns and formats
+	if example := scalarExample(prop); example != "" {
+		return example
+	}
+
+	// Numbers held as strings or BigDecimals take numeric strings
+	switch prop.PHPType.Numeric {
+	case config.NumericIntString:
+		return php.StringLiteral(numericTestValue(testNumber(prop, 123, true)))
+	case config.NumericDecimalString, config.NumericBigDecimal:
+		return php.StringLiteral(numericTestValue(testNumber(prop, 123.45, false)))
+	}
+
 	switch prop.PHPType.Name {
 	case "string":
-		return fmt.Sprintf("'test_%s'", strings.ToLower(prop.Name))
+		if value, ok := testString(prop); ok {
+			return php.StringLiteral(value)
+		}
+		return missingTestValue(prop)
 	case "int":
-		return "123"
+		return number(testNumber(prop, 123, true))
 	case "float":
-		return "123.45"
+		return number(testNumber(prop, 123.45, false))
 	case "bool":
 		return "true"
 	case "array":
-		return "[]"
+		if value := testArray(prop, path); value != "" {
+			return value
+		}
+		return missingTestValue(prop)
+	}
+
+	switch {
+	case prop.PHPType.Model != nil && !prop.PHPType.IsEnum:
+		if data := nestedTestData(prop.PHPType.Model, path); data != "" {
+			return data
+		}
+		return missingTestValue(prop)
 	default:
 		if prop.Required {
-			return fmt.Sprintf("'test_%s'", strings.ToLower(prop.Name))
+			return php.StringLiteral("test_" + strings.ToLower(prop.Name))
 		}
 		return "null"
 	}
@@ -146,22 +203,51 @@ func generateAssertions(className string, schema *config.SchemaModel) string {
 	var assertions []string
 	varName := strings.ToLower(className)
 
-	for _, prop := range schema.Properties {
-		expected := generatePropertyTestValue(prop)
-		assertions = append(assertions,
-			fmt.Sprintf("$this->assertEquals(%s, $%s->%s);", expected, varName, prop.Name))
+	path := visiting{}.with(schema)
+	for _, prop := range testPropertiesOf(schema, path) {
+		expected := propertyTestValue(prop, path)
+		actual := testPropertyValue(varName, prop)
+		var assertion string
+		switch {
+		case expected != "null" && isClass(prop.PHPType) && !prop.PHPType.IsEnum:
+			// Nested models are checked by their own tests
+			assertion = fmt.Sprintf("$this->assertInstanceOf(%s::class, %s);", prop.PHPType.Name, actual)
+		case prop.PHPType.IsArray && needsConversion(prop.PHPType):
+			items, _ := testArrayItems(prop, path)
+			assertion = fmt.Sprintf("$this->assertCount(%d, %s);", len(items), actual)
+		default:
+			assertion = fmt.Sprintf("$this->assertEquals(%s, %s);", expected, actual)
+		}
+		assertions = append(assertions, assertion)
 	}
 
 	return strings.Join(assertions, "\n        ")
 }
 
+// referencedClasses returns the model classes, other than the schema's own, that
+// generateAssertions refers to, for the use statements of the test.
+func referencedClasses(schema *config.SchemaModel) []string {
+	var classes []string
+	seen := map[string]bool{schema.Name: true}
+	path := visiting{}.with(schema)
+	for _, prop := range testPropertiesOf(schema, path) {
+		name := prop.PHPType.Name
+		if isClass(prop.PHPType) && !prop.PHPType.IsEnum && !seen[name] && propertyTestValue(prop, path) != "null" {
+			seen[name] = true
+			classes = append(classes, name)
+		}
+	}
+	sort.Strings(classes)
+	return classes
+}
+
 // generateSerializationAssertions creates assertions for testing serialization.
 func generateSerializationAssertions(schema *config.SchemaModel) string {
 	var assertions []string
 
-	for _, prop := range schema.Properties {
+	for _, prop := range testProperties(schema) {
 		assertions = append(assertions,
-			fmt.Sprintf("$this->assertArrayHasKey('%s', $result);", prop.Name))
+			fmt.Sprintf("$this->assertArrayHasKey(%s, $result);", php.StringLiteral(prop.Name)))
 	}
 
 	return strings.Join(assertions, "\n        ")
@@ -171,20 +257,109 @@ func generateSerializationAssertions(schema *config.SchemaModel) string {
 func generateMinimalTestData(schema *config.SchemaModel) string {
 	var properties []string
 
-	// Only include required properties for minimal test data
+	path := visiting{}.with(schema)
+	for _, prop := range minimalTestPropertiesOf(schema, path) {
+		value := propertyTestValue(prop, path)
+		properties = append(properties, fmt.Sprintf("%s => %s", php.StringLiteral(prop.Name), value))
+	}
+
+	return fmt.Sprintf("[\n        %s\n    ]", strings.Join(properties, ",\n        "))
+}
+
+// testProperties returns the properties that get a value in the test data. Optional
+// properties without a sample value are left out, as absent rather than null, since
+// they may not be nullable. So are properties toArray leaves out, which would not
+// survive the round trip the tests check, and required properties without a valid
+// value, whose model untestableProperty reports.
+func testProperties(schema *config.SchemaModel) []*config.Property {
+	return testPropertiesOf(schema, visiting{}.with(schema))
+}
+
+// testPropertiesOf is testProperties for a schema whose data is nested in the
+// data of the models on path.
+func testPropertiesOf(schema *config.SchemaModel, path visiting) []*config.Property {
+	var properties []*config.Property
+	for _, prop := range schema.Properties {
+		if !isSerialized(schema, prop) {
+			continue
+		}
+		value := propertyTestValue(prop, path)
+		if value != "" && (prop.Required || prop.PHPType.IsNullable || value != "null") {
+			properties = append(properties, prop)
+		}
+	}
+	return properties
+}
+
+// untestableProperty returns the name of the first required property of a schema
+// that no valid test value can be built for, or "" when there is none. The tests of
+// such a model are skipped, as fromArray would reject any data they could pass.
+func untestableProperty(schema *config.SchemaModel) string {
+	path := visiting{}.with(schema)
+	for _, prop := range schema.Properties {
+		if prop.Required && propertyTestValue(prop, path) == "" {
+			return prop.Name
+		}
+	}
+	return ""
+}
+
+// minimalTestProperties returns the required properties, or the first property that
+// gets a test value when none are required.
+func minimalTestProperties(schema *config.SchemaModel) []*config.Property {
+	return minimalTestPropertiesOf(schema, visiting{}.with(schema))
+}
+
+// minimalTestPropertiesOf is minimalTestProperties for a schema whose data is
+// nested in the data of the models on path.
+func minimalTestPropertiesOf(schema *config.SchemaModel, path visiting) []*config.Property {
+	var properties []*config.Property
 	for _, prop := range schema.Properties {
 		if prop.Required {
-			value := generatePropertyTestValue(prop)
-			properties = append(properties, fmt.Sprintf("'%s' => %s", prop.Name, value))
+			properties = append(properties, prop)
 		}
 	}
 
-	// If no required properties, include at least one property for testing
-	if len(properties) == 0 && len(schema.Properties) > 0 {
-		prop := schema.Properties[0]
-		value := generatePropertyTestValue(prop)
-		properties = append(properties, fmt.Sprintf("'%s' => %s", prop.Name, value))
+	if len(properties) > 0 {
+		return properties
+	}
+	if candidates := testPropertiesOf(schema, path); len(candidates) > 0 {
+		properties = append(properties, candidates[0])
+	}
+	return properties
+}
+
+// generateDefaultAssertions creates assertions that properties left out of the
+// minimal test data took their schema defaults.
+func generateDefaultAssertions(className string, schema *config.SchemaModel) string {
+	minimal := make(map[*config.Property]bool)
+	for _, prop := range minimalTestProperties(schema) {
+		minimal[prop] = true
 	}
 
-	return fmt.Sprintf("[\n        %s\n    ]", strings.Join(properties, ",\n        "))
+	var assertions []string
+	varName := strings.ToLower(className)
+	for _, prop := range schema.Properties {
+		if minimal[prop] || !hasDefault(prop) {
+			continue
+		}
+		expected, err := php.Literal(prop.Default)
+		if err != nil {
+			continue
+		}
+		assertions = append(assertions,
+			fmt.Sprintf("$this->assertEquals(%s, %s);", expected, testPropertyValue(varName, prop)))
+	}
+
+	return strings.Join(assertions, "\n        ")
+}
+
+// testPropertyValue returns the expression reading a property as a JSON value in a
+// test, taking the backing value of enums.
+func testPropertyValue(varName string, prop *config.Property) string {
+	value := fmt.Sprintf("$%s->%s", varName, propertyName(prop))
+	if prop.PHPType.IsEnum {
+		value += "?->value"
+	}
+	return value
 }
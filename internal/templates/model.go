@@ -0,0 +1,426 @@
+package templates
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/floriscornel/piak/internal/php"
+)
+
+// UndefinedClass is the name of the generated enum whose single case marks an
+// optional property as not set.
+const UndefinedClass = "Undefined"
+
+// undefinedValue is the enum case of UndefinedClass.
+const undefinedValue = "Value"
+
+// NewModelData builds the template data for a model, including the PHP class whose
+// members the model partials print.
+func NewModelData(schema *config.SchemaModel, cfg *config.GeneratorConfig) ModelData {
+	imports := php.NewImports(cfg.Namespace, schema.Name)
+	return ModelData{
+		SchemaModel: schema,
+		Config:      cfg,
+		Class:       buildModelClass(schema, cfg, imports),
+		Uses:        imports.Uses(),
+		DocTags:     dataTypeTags(schema),
+	}
+}
+
+// buildModelClass builds the PHP class of a model, naming the classes it refers to
+// through imports.
+func buildModelClass(schema *config.SchemaModel, cfg *config.GeneratorConfig, imports *php.Imports) *php.Class {
+	constructor := buildConstructor(schema, imports)
+	class := &php.Class{
+		Name:     schema.Name,
+		Readonly: true,
+		Methods: []*php.Method{
+			constructor,
+			buildFromArrayMethod(schema, maxDepth(cfg), imports),
+			buildToArrayMethod(schema, imports),
+		},
+	}
+	if validate := buildValidateMethod(schema, imports); validate != nil {
+		constructor.Body = []php.Stmt{
+			validateCall(schema, imports, "$violations = ", ";", 2, func(prop *config.Property) string {
+				return "$this->" + propertyName(prop)
+			}),
+			throwViolations(imports),
+		}
+		class.Methods = append(class.Methods, validate)
+	}
+	return class
+}
+
+// constructorOrder returns the properties in constructor order: required first, then optional.
+func constructorOrder(model *config.SchemaModel) []*config.Property {
+	var requiredProps []*config.Property
+	var optionalProps []*config.Property
+
+	for _, prop := range model.Properties {
+		if prop.Required {
+			requiredProps = append(requiredProps, prop)
+		} else {
+			optionalProps = append(optionalProps, prop)
+		}
+	}
+
+	return append(requiredProps, optionalProps...)
+}
+
+// buildConstructor builds a constructor promoting every property. Optional
+// properties default to their schema default, or to the Undefined sentinel. Each
+// property is documented from its schema, and arrays get their item types.
+func buildConstructor(model *config.SchemaModel, imports *php.Imports) *php.Method {
+	method := &php.Method{Name: "__construct"}
+	doc := php.NewDocBlock()
+
+	for _, prop := range constructorOrder(model) {
+		param := &php.Param{
+			Name:    propertyName(prop),
+			Type:    propertyType(prop, imports),
+			Promote: php.Public,
+			Doc:     propertyDoc(prop),
+		}
+		typ := param.Type
+		if prop.PHPType.Name == arrayType {
+			typ = propertyDocType(prop, imports)
+		}
+		if hasDefault(prop) {
+			param.Default = defaultValue(prop, imports)
+			doc.Tag("param", fmt.Sprintf("%s $%s Defaults to %s.", typ, param.Name, param.Default))
+		} else {
+			if !prop.Required {
+				param.Default = undefined(imports)
+			}
+			if typ != param.Type {
+				doc.Tag("param", fmt.Sprintf("%s $%s", typ, param.Name))
+			}
+		}
+		method.Params = append(method.Params, param)
+	}
+
+	if !doc.IsEmpty() {
+		method.Doc = doc
+	}
+	return method
+}
+
+// hasDefault reports whether an optional property has a schema default, which it
+// takes when absent instead of being undefined.
+func hasDefault(prop *config.Property) bool {
+	return !prop.Required && prop.Default != nil
+}
+
+// isUndefinable reports whether a property can hold the Undefined sentinel.
+func isUndefinable(prop *config.Property) bool {
+	return !prop.Required && !hasDefault(prop)
+}
+
+// defaultValue returns a property's default as a PHP constant expression.
+func defaultValue(prop *config.Property, imports *php.Imports) string {
+	if prop.DefaultCase != "" {
+		return imports.Name(prop.PHPType.Name) + "::" + prop.DefaultCase
+	}
+	// The generator only keeps defaults that Literal accepts
+	value, _ := php.Literal(prop.Default)
+	return value
+}
+
+// propertyType returns the declared type of a property. Optional properties without
+// a default also accept the Undefined sentinel, and nullable ones null, so "not
+// set" and "set to null" stay distinct.
+func propertyType(prop *config.Property, imports *php.Imports) string {
+	if !isUndefinable(prop) {
+		return nativeType(prop.PHPType, imports)
+	}
+
+	base := prop.PHPType
+	base.IsNullable = false
+	typ := nativeType(base, imports)
+	// mixed already includes the sentinel and null, and cannot be part of a union
+	if typ == "mixed" {
+		return typ
+	}
+
+	typ += "|" + imports.Name(UndefinedClass)
+	if prop.PHPType.IsNullable {
+		typ += "|null"
+	}
+	return typ
+}
+
+// propertyDocType returns the PHPDoc type of a property, as propertyType does for
+// the declared type.
+func propertyDocType(prop *config.Property, imports *php.Imports) string {
+	if !isUndefinable(prop) {
+		return docType(prop.PHPType)
+	}
+
+	base := prop.PHPType
+	base.IsNullable = false
+	typ := docType(base)
+	if typ == "mixed" {
+		return typ
+	}
+
+	typ += "|" + imports.Name(UndefinedClass)
+	if prop.PHPType.IsNullable {
+		typ += "|null"
+	}
+	return typ
+}
+
+// maxDepth returns the nesting depth recursive models accept.
+func maxDepth(cfg *config.GeneratorConfig) int {
+	if cfg.MaxDepth > 0 {
+		return cfg.MaxDepth
+	}
+	return config.DefaultMaxDepth
+}
+
+// undefined returns the expression for the Undefined sentinel.
+func undefined(imports *php.Imports) string {
+	return imports.Name(UndefinedClass) + "::" + undefinedValue
+}
+
+// buildFromArrayMethod builds a fromArray method validating required fields and enums.
+// It reports their violations together with those of the nested models, under the
+// path of their property, and those of the constraints. Its data is typed with the
+// model's data type alias, when it has one. Recursive models take the nesting depth
+// of their data and reject data nested deeper than maxDepth, as hostile input could
+// otherwise exhaust the stack.
+func buildFromArrayMethod(model *config.SchemaModel, maxDepth int, imports *php.Imports) *php.Method {
+	dataType := "array<string, mixed>"
+	if model.DataType != "" && !model.Recursive {
+		dataType = model.DataType
+	}
+	method := &php.Method{
+		Name:       "fromArray",
+		Doc:        php.NewDocBlock("Create instance from array data").Tag("param", dataType+" $data"),
+		Static:     true,
+		Params:     []*php.Param{{Name: "data", Type: "array"}},
+		ReturnType: "self",
+	}
+
+	h := hydration{imports: imports}
+	if model.Recursive {
+		// Models on the same cycle pass data typed array<string, mixed>, as PHPStan
+		// rejects circular type aliases, so the alias is applied to it here
+		h.depth = "$depth + 1"
+		method.Params = append(method.Params, &php.Param{Name: "depth", Type: "int", Default: "0"})
+		method.Doc.Tag("param", "int $depth Nesting depth of the data, at most "+strconv.Itoa(maxDepth)).
+			Tag("throws", imports.Name(ValidationExceptionClass))
+		message := php.StringLiteral(fmt.Sprintf("is nested more than %d levels deep", maxDepth))
+		throw := fmt.Sprintf("throw new %s([['path' => '$', 'message' => %s]]);",
+			imports.Name(ValidationExceptionClass), message)
+		method.Body = append(method.Body, php.If(fmt.Sprintf("$depth > %d", maxDepth), php.Line(throw)), php.BlankLine{})
+		if model.DataType != "" {
+			method.Body = append(method.Body, php.Line(fmt.Sprintf("/** @var %s $data */", model.DataType)))
+		}
+	}
+
+	// Collect missing required fields and unknown enum values, and hydrate nested
+	// models collecting their violations, so they are reported together. isset also
+	// rejects null, which only nullable fields may hold.
+	var checks []php.Stmt
+	for _, prop := range model.Properties {
+		key := php.StringLiteral(prop.Name)
+		if prop.Required {
+			condition := fmt.Sprintf("!isset($data[%s])", key)
+			if prop.PHPType.IsNullable {
+				condition = fmt.Sprintf("!array_key_exists(%s, $data)", key)
+			}
+			checks = append(checks, php.If(condition, addViolation(prop, "is required")))
+		}
+		if prop.PHPType.IsEnum {
+			checks = append(checks, php.If(
+				fmt.Sprintf("isset($data[%s]) && !in_array($data[%s], array_column(%s::cases(), 'value'), true)",
+					key, key, imports.Name(prop.PHPType.Name)),
+				addViolation(prop, "must be one of "+enumMessage(prop.EnumValues)),
+			))
+		}
+	}
+	nested, hydrated := h.nestedModels(model)
+	if len(checks) > 0 || len(nested) > 0 {
+		if !model.Recursive {
+			method.Doc.Tag("throws", imports.Name(ValidationExceptionClass))
+		}
+		method.Body = append(method.Body, php.Line("$violations = [];"))
+		method.Body = append(method.Body, checks...)
+		method.Body = append(method.Body, nested...)
+		method.Body = append(method.Body, php.BlankLine{}, throwAllViolations(model, h, hydrated), php.BlankLine{})
+	}
+
+	// Pass arguments in constructor order. Absent optional fields take their
+	// default or stay undefined.
+	args := &php.List{Open: "return new self(", Close: ");"}
+	for _, prop := range constructorOrder(model) {
+		key := php.StringLiteral(prop.Name)
+		value := h.value(prop.PHPType, fmt.Sprintf("$data[%s]", key), 0)
+		switch {
+		case throwsViolations(prop.PHPType):
+			value = "$" + localName(prop)
+		case !prop.Required:
+			fallback := undefined(imports)
+			if hasDefault(prop) {
+				fallback = defaultValue(prop, imports)
+			}
+			// PHP requires parentheses around nested ternaries
+			if strings.Contains(value, " ? ") {
+				value = "(" + value + ")"
+			}
+			value = fmt.Sprintf("array_key_exists(%s, $data) ? %s : %s", key, value, fallback)
+		}
+		args.Items = append(args.Items, php.Line(value))
+	}
+	method.Body = append(method.Body, args)
+
+	return method
+}
+
+// nestedModels returns the statements of fromArray hydrating the properties holding
+// models into variables, collecting the violations of the models under the key of
+// the property, and the variables of the required ones, which stay unset when their
+// data is missing or invalid.
+func (h hydration) nestedModels(model *config.SchemaModel) ([]php.Stmt, []string) {
+	var stmts, catch []php.Stmt
+	var required []string
+	for _, prop := range model.Properties {
+		if !throwsViolations(prop.PHPType) {
+			continue
+		}
+		key := php.StringLiteral(prop.Name)
+		variable := "$" + localName(prop)
+		phpType := prop.PHPType
+		condition := fmt.Sprintf("array_key_exists(%s, $data)", key)
+		switch {
+		case !prop.Required:
+			fallback := undefined(h.imports)
+			if hasDefault(prop) {
+				fallback = defaultValue(prop, h.imports)
+			}
+			stmts = append(stmts, php.Line(variable+" = "+fallback+";"))
+		case phpType.IsNullable:
+			// Nulls are passed on as they are
+			stmts = append(stmts, php.Line(variable+" = null;"))
+			condition = fmt.Sprintf("isset($data[%s])", key)
+			phpType.IsNullable = false
+		default:
+			condition = fmt.Sprintf("isset($data[%s])", key)
+			required = append(required, variable)
+		}
+
+		catch = []php.Stmt{php.Line(fmt.Sprintf("array_push($violations, ...$exception->under(%s)->violations);", key))}
+		hydrate := php.Line(fmt.Sprintf("%s = %s;", variable, h.value(phpType, fmt.Sprintf("$data[%s]", key), 0)))
+		stmts = append(stmts, php.If(condition, php.Try([]php.Stmt{hydrate},
+			h.imports.Name(ValidationExceptionClass)+" $exception", catch...)))
+	}
+	return stmts, required
+}
+
+// throwAllViolations returns the statement of fromArray throwing the violations it
+// collected, if any, with those of the constraints on the values it has. Those of
+// missing or invalid data are passed to the validate method as Undefined.
+func throwAllViolations(model *config.SchemaModel, h hydration, hydrated []string) php.Stmt {
+	condition := "$violations !== []"
+	if len(hydrated) > 0 {
+		// isset() tells PHPStan that the required models were hydrated
+		condition += fmt.Sprintf(" || !isset(%s)", strings.Join(hydrated, ", "))
+	}
+	exception := h.imports.Name(ValidationExceptionClass)
+	if buildValidateMethod(model, h.imports) == nil {
+		return php.If(condition, php.Line(fmt.Sprintf("throw new %s($violations);", exception)))
+	}
+
+	open := fmt.Sprintf("throw new %s([...$violations, ...", exception)
+	return php.If(condition, validateCall(model, h.imports, open, "]);", 3, func(prop *config.Property) string {
+		key := php.StringLiteral(prop.Name)
+		value := fmt.Sprintf("$data[%s]", key)
+		if !prop.PHPType.IsArray {
+			value = h.value(prop.PHPType, value, 0)
+		}
+		if strings.Contains(value, " ? ") {
+			value = "(" + value + ")"
+		}
+		if prop.PHPType.IsNullable {
+			return fmt.Sprintf("array_key_exists(%s, $data) ? %s : %s", key, value, undefined(h.imports))
+		}
+		return fmt.Sprintf("isset($data[%s]) ? %s : %s", key, value, undefined(h.imports))
+	}))
+}
+
+// buildToArrayMethod builds a toArray method. Optional properties that were never
+// set are left out rather than serialized as null, and so are readOnly properties.
+func buildToArrayMethod(model *config.SchemaModel, imports *php.Imports) *php.Method {
+	items := &php.List{Open: "return [", Close: "];"}
+	hasUndefinable := false
+	for _, prop := range model.Properties {
+		if !isSerialized(model, prop) {
+			continue
+		}
+		items.Items = append(items.Items,
+			php.Line(fmt.Sprintf("%s => %s", php.StringLiteral(prop.Name), serialize(prop, imports))))
+		hasUndefinable = hasUndefinable || isUndefinable(prop)
+	}
+
+	var body php.Stmt = items
+	if hasUndefinable {
+		items.Open, items.Close = "[", "]"
+		body = &php.List{
+			Open: "return array_filter(",
+			Items: []php.Stmt{
+				items,
+				php.Line(fmt.Sprintf("static fn (mixed $value): bool => !$value instanceof %s", imports.Name(UndefinedClass))),
+			},
+			Close: ");",
+		}
+	}
+
+	return &php.Method{
+		Name:       "toArray",
+		Doc:        php.NewDocBlock("Convert instance to array").Tag("return", "array<string, mixed>"),
+		ReturnType: "array",
+		Body:       []php.Stmt{body},
+	}
+}
+
+// isSerialized reports whether toArray includes a property. toArray builds the
+// payload sent to the API, which must not contain readOnly properties; response
+// variants are never sent and keep them, so what was received round-trips.
+func isSerialized(model *config.SchemaModel, prop *config.Property) bool {
+	return !prop.ReadOnly || model.Direction == config.DirectionResponse
+}
+
+// propertyName returns the PHP name of a property, deriving one from the wire name
+// for models that were built without the generator's name mapping.
+func propertyName(prop *config.Property) string {
+	if prop.PHPName != "" {
+		return prop.PHPName
+	}
+	return php.Identifier(prop.Name)
+}
+
+// serialize returns the expression converting the property back to a JSON value.
+func serialize(prop *config.Property, imports *php.Imports) string {
+	value := "$this->" + propertyName(prop)
+	if serialized := serializeValue(prop.PHPType, value, imports, 0); !isUndefinable(prop) || serialized == value {
+		return serialized
+	}
+
+	// Leave the Undefined sentinel for toArray to filter out, and null as it is
+	set := prop.PHPType
+	set.IsNullable = false
+	var guard string
+	switch {
+	case set.IsArray:
+		guard = fmt.Sprintf("is_array(%s)", value)
+	case set.Name == "string":
+		guard = fmt.Sprintf("is_string(%s)", value)
+	default:
+		guard = fmt.Sprintf("%s instanceof %s", value, imports.Name(set.Name))
+	}
+	return fmt.Sprintf("%s ? %s : %s", guard, serializeValue(set, value, imports, 0), value)
+}
@@ -0,0 +1,499 @@
+package templates_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/floriscornel/piak/internal/php"
+	"github.com/floriscornel/piak/internal/templates"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewModelData_OptionalAndNullable(t *testing.T) {
+	schema := &config.SchemaModel{
+		Name: "Pet",
+		Properties: []*config.Property{
+			{Name: "id", PHPType: config.PHPType{Name: "int"}, Required: true},
+			{Name: "note", PHPType: config.PHPType{Name: "string", IsNullable: true}, Required: true},
+			{Name: "name", PHPType: config.PHPType{Name: "string"}},
+			{Name: "tag", PHPType: config.PHPType{Name: "string", IsNullable: true}},
+		},
+	}
+	data := templates.NewModelData(schema, &config.GeneratorConfig{Namespace: "App"})
+
+	constructor := php.PrintMethod(data.Class.Method("__construct"), 0)
+	assert.Contains(t, constructor, "public int $id,")
+	assert.Contains(t, constructor, "public ?string $note,")
+	assert.Contains(t, constructor, "public string|Undefined $name = Undefined::Value,")
+	assert.Contains(t, constructor, "public string|Undefined|null $tag = Undefined::Value,")
+
+	fromArray := php.PrintMethod(data.Class.Method("fromArray"), 0)
+	assert.Contains(t, fromArray, "if (!isset($data['id'])) {")
+	assert.Contains(t, fromArray, "if (!array_key_exists('note', $data)) {")
+	assert.Contains(t, fromArray, "array_key_exists('name', $data) ? $data['name'] : Undefined::Value,")
+
+	toArray := php.PrintMethod(data.Class.Method("toArray"), 0)
+	assert.Contains(t, toArray, "return array_filter(")
+	assert.Contains(t, toArray, "!$value instanceof Undefined")
+}
+
+func TestNewModelData_RequiredOnly(t *testing.T) {
+	schema := &config.SchemaModel{
+		Name:       "Pet",
+		Properties: []*config.Property{{Name: "id", PHPType: config.PHPType{Name: "int"}, Required: true}},
+	}
+	data := templates.NewModelData(schema, &config.GeneratorConfig{Namespace: "App"})
+
+	toArray := php.PrintMethod(data.Class.Method("toArray"), 0)
+	assert.Contains(t, toArray, "return [")
+	assert.NotContains(t, toArray, "Undefined")
+}
+
+func TestNewModelData_ReadOnly(t *testing.T) {
+	properties := []*config.Property{
+		{Name: "id", PHPType: config.PHPType{Name: "int"}, Required: true, ReadOnly: true},
+		{Name: "name", PHPType: config.PHPType{Name: "string"}, Required: true},
+	}
+	cfg := &config.GeneratorConfig{Namespace: "App"}
+
+	data := templates.NewModelData(&config.SchemaModel{Name: "Pet", Properties: properties}, cfg)
+	toArray := php.PrintMethod(data.Class.Method("toArray"), 0)
+	assert.NotContains(t, toArray, "'id'")
+	assert.Contains(t, toArray, "'name' => $this->name,")
+
+	response := &config.SchemaModel{Name: "PetResponse", Properties: properties, Direction: config.DirectionResponse}
+	data = templates.NewModelData(response, cfg)
+	assert.Contains(t, php.PrintMethod(data.Class.Method("toArray"), 0), "'id' => $this->id,")
+}
+
+func TestNewModelData_DefaultsAndEnums(t *testing.T) {
+	schema := &config.SchemaModel{
+		Name: "Pet",
+		Properties: []*config.Property{
+			{Name: "status", PHPType: config.PHPType{Name: "Status", IsEnum: true}, Required: true},
+			{Name: "count", PHPType: config.PHPType{Name: "int"}, Default: 10},
+			{Name: "kind", PHPType: config.PHPType{Name: "Kind", IsEnum: true}, Default: "cat", DefaultCase: "Cat"},
+			{Name: "priority", PHPType: config.PHPType{Name: "Priority", IsEnum: true}},
+		},
+	}
+	data := templates.NewModelData(schema, &config.GeneratorConfig{Namespace: "App"})
+
+	constructor := php.PrintMethod(data.Class.Method("__construct"), 0)
+	assert.Contains(t, constructor, "public int $count = 10,")
+	assert.Contains(t, constructor, "public Kind $kind = Kind::Cat,")
+	assert.Contains(t, constructor, "@param int $count Defaults to 10.")
+
+	fromArray := php.PrintMethod(data.Class.Method("fromArray"), 0)
+	assert.Contains(t, fromArray, "Status::from($data['status']),")
+	assert.Contains(t, fromArray, "array_key_exists('count', $data) ? $data['count'] : 10,")
+	assert.Contains(t, fromArray, "array_key_exists('kind', $data) ? Kind::from($data['kind']) : Kind::Cat,")
+
+	toArray := php.PrintMethod(data.Class.Method("toArray"), 0)
+	assert.Contains(t, toArray, "'status' => $this->status->value,")
+	assert.Contains(t, toArray, "'kind' => $this->kind->value,")
+	assert.Contains(t, toArray, "$this->priority instanceof Priority ? $this->priority->value : $this->priority")
+}
+
+func TestNewEnumData(t *testing.T) {
+	data := templates.NewEnumData(&config.SchemaModel{
+		Name:      "Priority",
+		IsEnum:    true,
+		EnumCases: []*config.EnumCase{{Name: "Low", Value: 1}, {Name: "High", Value: 2}},
+	}, &config.GeneratorConfig{Namespace: "App"})
+
+	assert.Equal(t, "int", data.Enum.BackingType)
+	assert.Equal(t, "case Low = 1;\ncase High = 2;", php.PrintEnumCases(data.Enum, 0))
+}
+
+func TestNewEnumData_DeprecatedCases(t *testing.T) {
+	schema := &config.SchemaModel{
+		Name:      "Status",
+		IsEnum:    true,
+		EnumCases: []*config.EnumCase{{Name: "Sold", Value: "sold", Deprecated: true}},
+	}
+
+	data := templates.NewEnumData(schema, &config.GeneratorConfig{Namespace: "App"})
+	assert.Equal(t, "/**\n * @deprecated\n */\ncase Sold = 'sold';", php.PrintEnumCases(data.Enum, 0))
+
+	data = templates.NewEnumData(schema, &config.GeneratorConfig{Namespace: "App", DeprecatedAttributes: true})
+	assert.Contains(t, php.PrintEnumCases(data.Enum, 0), "#[\\Deprecated]\ncase Sold = 'sold';")
+}
+
+func TestNewModelData_Validation(t *testing.T) {
+	minLength, maxItems := uint64(2), uint64(5)
+	minimum := 0.0
+	schema := &config.SchemaModel{
+		Name: "Pet",
+		Properties: []*config.Property{
+			{
+				Name:        "first-name",
+				PHPName:     "firstName",
+				PHPType:     config.PHPType{Name: "string"},
+				Required:    true,
+				Constraints: &config.Constraints{MinLength: &minLength, Pattern: "^[a-z/]+$"},
+			},
+			{
+				Name:        "age",
+				PHPType:     config.PHPType{Name: "int"},
+				Constraints: &config.Constraints{Minimum: &minimum, ExclusiveMinimum: true},
+			},
+			{
+				Name:        "tags",
+				PHPType:     config.PHPType{Name: "array", IsArray: true},
+				Constraints: &config.Constraints{MaxItems: &maxItems},
+			},
+			{Name: "level", PHPType: config.PHPType{Name: "string", IsNullable: true}, EnumValues: []any{"low", "high"}},
+		},
+	}
+	data := templates.NewModelData(schema, &config.GeneratorConfig{Namespace: "App"})
+
+	constructor := php.PrintMethod(data.Class.Method("__construct"), 0)
+	assert.Contains(t, constructor,
+		"$violations = self::validate($this->firstName, $this->age, $this->tags, $this->level);")
+	assert.Contains(t, constructor, "throw new ValidationException($violations);")
+
+	validate := php.PrintMethod(data.Class.Method("validate"), 0)
+	for _, expected := range []string{
+		"@param array<mixed>|Undefined $tags",
+		"@return list<array{path: string, message: string}>",
+		"private static function validate(",
+		"string|Undefined $firstName,",
+		"if (!$firstName instanceof Undefined && mb_strlen($firstName) < 2) {",
+		`$violations[] = ['path' => '$["first-name"]', 'message' => 'must be at least 2 characters long'];`,
+		`preg_match('/^[a-z\\/]+$/u', $firstName) !== 1`,
+		"if (!$age instanceof Undefined && $age <= 0) {",
+		"'message' => 'must contain at most 5 items'",
+		"!$level instanceof Undefined && $level !== null && !in_array($level, ['low', 'high'], true)",
+		"return $violations;",
+	} {
+		assert.Contains(t, validate, expected)
+	}
+
+	// Missing fields are reported with the violations of the values that are there
+	fromArray := php.PrintMethod(data.Class.Method("fromArray"), 0)
+	for _, expected := range []string{
+		"@throws ValidationException",
+		`$violations[] = ['path' => '$["first-name"]', 'message' => 'is required'];`,
+		"throw new ValidationException([...$violations, ...self::validate(",
+		"isset($data['first-name']) ? $data['first-name'] : Undefined::Value,",
+		"array_key_exists('level', $data) ? $data['level'] : Undefined::Value,",
+		")]);",
+	} {
+		assert.Contains(t, fromArray, expected)
+	}
+}
+
+func TestNewModelData_NestedTypes(t *testing.T) {
+	owner := &config.SchemaModel{Name: "Owner", DataType: "OwnerData"}
+	ownerType := config.PHPType{Name: "Owner", DocComment: "Owner", DataType: "OwnerData", Model: owner}
+	statusType := config.PHPType{Name: "Status", IsEnum: true, DocComment: "Status", DataType: "string"}
+	owners := config.PHPType{
+		Name: "array", IsArray: true, Items: &ownerType,
+		DocComment: "list<Owner>", DataType: "list<OwnerData>",
+	}
+	schema := &config.SchemaModel{
+		Name:     "Pet",
+		DataType: "PetData",
+		Properties: []*config.Property{
+			{Name: "owner", PHPType: ownerType, Required: true},
+			{Name: "owners", PHPType: owners, Required: true},
+			{Name: "byName", PHPType: config.PHPType{
+				Name: "array", IsArray: true, IsMap: true, Items: &owners,
+				DocComment: "array<string, list<Owner>>", DataType: "array<string, list<OwnerData>>",
+			}},
+			{Name: "statuses", PHPType: config.PHPType{
+				Name: "array", IsArray: true, IsNullable: true, Items: &statusType,
+				DocComment: "list<Status>", DataType: "list<string>",
+			}, Required: true},
+		},
+	}
+	data := templates.NewModelData(schema, &config.GeneratorConfig{Namespace: "App"})
+
+	assert.Equal(t, []string{
+		"phpstan-type PetData array{owner: OwnerData, owners: list<OwnerData>, " +
+			"byName?: array<string, list<OwnerData>>, statuses: list<string>|null}",
+		"phpstan-import-type OwnerData from Owner",
+	}, data.DocTags)
+
+	constructor := php.PrintMethod(data.Class.Method("__construct"), 0)
+	assert.Contains(t, constructor, "@param list<Owner> $owners")
+	assert.Contains(t, constructor, "@param list<Status>|null $statuses")
+	assert.Contains(t, constructor, "@param array<string, list<Owner>>|Undefined $byName")
+
+	fromArray := php.PrintMethod(data.Class.Method("fromArray"), 0)
+	for _, expected := range []string{
+		"@param PetData $data",
+		"$owner = Owner::fromArray($data['owner']);",
+		"$owners = array_values(ValidationException::map(Owner::fromArray(...), $data['owners']));",
+		"$byName = ValidationException::map(static fn (array $item): array => " +
+			"array_values(ValidationException::map(Owner::fromArray(...), $item)), $data['byName']);",
+		"if ($violations !== [] || !isset($owner, $owners)) {",
+		"        $owner,\n        $owners,\n",
+		"$data['statuses'] === null ? null : array_map(Status::from(...), $data['statuses']),",
+	} {
+		assert.Contains(t, fromArray, expected)
+	}
+
+	toArray := php.PrintMethod(data.Class.Method("toArray"), 0)
+	for _, expected := range []string{
+		"'owner' => $this->owner->toArray(),",
+		"'owners' => array_map(static fn (Owner $item): array => $item->toArray(), $this->owners),",
+		"'byName' => is_array($this->byName) ? array_map(static fn (array $item): array => " +
+			"array_map(static fn (Owner $item2): array => $item2->toArray(), $item), $this->byName) : $this->byName,",
+		"'statuses' => $this->statuses === null ? null : " +
+			"array_map(static fn (Status $item): string => $item->value, $this->statuses),",
+	} {
+		assert.Contains(t, toArray, expected)
+	}
+}
+
+func TestNewModelData_NestedViolations(t *testing.T) {
+	minLength := uint64(1)
+	category := &config.SchemaModel{Name: "Category", DataType: "CategoryData"}
+	categoryType := config.PHPType{Name: "Category", DocComment: "Category", DataType: "CategoryData", Model: category}
+	schema := &config.SchemaModel{
+		Name:     "Pet",
+		DataType: "PetData",
+		Properties: []*config.Property{
+			{Name: "category", PHPType: categoryType, Required: true},
+			{
+				Name: "name", PHPType: config.PHPType{Name: "string", DataType: "string"}, Required: true,
+				Constraints: &config.Constraints{MinLength: &minLength},
+			},
+		},
+	}
+	data := templates.NewModelData(schema, &config.GeneratorConfig{Namespace: "App"})
+
+	// A violation of the category is reported as $.category.name, with the missing
+	// name and its constraints
+	assert.Equal(t, `/**
+ * Create instance from array data
+ *
+ * @param PetData $data
+ * @throws ValidationException
+ */
+public static function fromArray(array $data): self
+{
+    $violations = [];
+    if (!isset($data['category'])) {
+        $violations[] = ['path' => '$.category', 'message' => 'is required'];
+    }
+    if (!isset($data['name'])) {
+        $violations[] = ['path' => '$.name', 'message' => 'is required'];
+    }
+    if (isset($data['category'])) {
+        try {
+            $category = Category::fromArray($data['category']);
+        } catch (ValidationException $exception) {
+            array_push($violations, ...$exception->under('category')->violations);
+        }
+    }
+
+    if ($violations !== [] || !isset($category)) {
+        throw new ValidationException([...$violations, ...self::validate(
+            isset($data['name']) ? $data['name'] : Undefined::Value,
+        )]);
+    }
+
+    return new self(
+        $category,
+        $data['name'],
+    );
+}`, php.PrintMethod(data.Class.Method("fromArray"), 0))
+}
+
+func TestNewModelData_NumericTypes(t *testing.T) {
+	bigDecimal := config.PHPType{
+		Name: `\Brick\Math\BigDecimal`, DocComment: `\Brick\Math\BigDecimal`,
+		DataType: "int|float|string", Numeric: config.NumericBigDecimal,
+	}
+	idType := config.PHPType{
+		Name: "string", DocComment: "string", DataType: "int|string", Numeric: config.NumericIntString,
+	}
+	minimum := 0.0
+	schema := &config.SchemaModel{
+		Name: "Order",
+		Properties: []*config.Property{
+			{Name: "id", PHPType: idType, Required: true},
+			{Name: "total", PHPType: bigDecimal, Required: true, Constraints: &config.Constraints{Minimum: &minimum}},
+			{Name: "rate", PHPType: config.PHPType{
+				Name: "string", IsNullable: true, DocComment: "string", DataType: "int|float|string",
+				Numeric: config.NumericDecimalString,
+			}, Required: true},
+			{Name: "ids", PHPType: config.PHPType{
+				Name: "array", IsArray: true, Items: &idType, DocComment: "list<string>", DataType: "list<int|string>",
+			}},
+		},
+	}
+	data := templates.NewModelData(schema, &config.GeneratorConfig{Namespace: "App"})
+
+	assert.Equal(t, []php.Use{{Name: `Brick\Math\BigDecimal`}}, data.Uses)
+
+	constructor := php.PrintMethod(data.Class.Method("__construct"), 0)
+	assert.Contains(t, constructor, "public string $id,")
+	assert.Contains(t, constructor, "public BigDecimal $total,")
+	assert.Contains(t, constructor, "public ?string $rate,")
+
+	fromArray := php.PrintMethod(data.Class.Method("fromArray"), 0)
+	for _, expected := range []string{
+		"(string) $data['id'],",
+		"BigDecimal::of($data['total']),",
+		"$data['rate'] === null ? null : (string) $data['rate'],",
+		"array_map(static fn (int|string $item): string => (string) $item, $data['ids'])",
+	} {
+		assert.Contains(t, fromArray, expected)
+	}
+
+	toArray := php.PrintMethod(data.Class.Method("toArray"), 0)
+	for _, expected := range []string{
+		"'id' => filter_var($this->id, FILTER_VALIDATE_INT, FILTER_NULL_ON_FAILURE) ?? $this->id,",
+		"'total' => (string) $this->total,",
+		"'rate' => $this->rate,",
+		"'ids' => is_array($this->ids) ? array_map(static fn (string $item): int|string => " +
+			"filter_var($item, FILTER_VALIDATE_INT, FILTER_NULL_ON_FAILURE) ?? $item, $this->ids) : $this->ids,",
+	} {
+		assert.Contains(t, toArray, expected)
+	}
+
+	assert.Contains(t, php.PrintMethod(data.Class.Method("validate"), 0),
+		"if (!$total instanceof Undefined && $total->isLessThan('0')) {")
+}
+
+func TestNewModelData_PropertyDocs(t *testing.T) {
+	maxLength := uint64(20)
+	schema := &config.SchemaModel{
+		Name: "Pet",
+		Properties: []*config.Property{{
+			Name:        "name",
+			PHPType:     config.PHPType{Name: "string"},
+			Required:    true,
+			Description: "The **name** of the pet.",
+			Constraints: &config.Constraints{MaxLength: &maxLength},
+			Deprecated:  true,
+			OpenAPIType: &openapi3.Schema{
+				Format:       "nickname",
+				Example:      "Rex",
+				ExternalDocs: &openapi3.ExternalDocs{URL: "https://example.com/names"},
+			},
+		}},
+	}
+	data := templates.NewModelData(schema, &config.GeneratorConfig{Namespace: "App"})
+
+	expected := `    /**
+     * The name of the pet.
+     *
+     * Format: nickname.
+     * Example: "Rex"
+     * Constraints: maxLength 20.
+     *
+     * @see https://example.com/names
+     * @deprecated
+     */
+    public string $name,`
+	assert.Contains(t, php.PrintMethod(data.Class.Method("__construct"), 0), expected)
+}
+
+func TestNewModelData_Recursive(t *testing.T) {
+	category := &config.SchemaModel{Name: "Category", DataType: "CategoryData", Recursive: true}
+	categoryType := config.PHPType{
+		Name: "Category", DocComment: "Category", DataType: "array<string, mixed>", Model: category,
+	}
+	category.Properties = []*config.Property{
+		{Name: "name", PHPType: config.PHPType{Name: "string", DataType: "string"}, Required: true},
+		{Name: "children", PHPType: config.PHPType{
+			Name: "array", IsArray: true, Items: &categoryType,
+			DocComment: "list<Category>", DataType: "list<array<string, mixed>>",
+		}},
+	}
+	data := templates.NewModelData(category, &config.GeneratorConfig{Namespace: "App", MaxDepth: 10})
+
+	assert.Equal(t, []string{
+		"phpstan-type CategoryData array{name: string, children?: list<array<string, mixed>>}",
+	}, data.DocTags)
+
+	fromArray := php.PrintMethod(data.Class.Method("fromArray"), 0)
+	for _, expected := range []string{
+		"@param array<string, mixed> $data",
+		"public static function fromArray(array $data, int $depth = 0): self",
+		"if ($depth > 10) {",
+		"throw new ValidationException([['path' => '$', 'message' => 'is nested more than 10 levels deep']]);",
+		"/** @var CategoryData $data */",
+		"array_values(ValidationException::map(static fn (array $item): Category => " +
+			"Category::fromArray($item, $depth + 1), $data['children']))",
+	} {
+		assert.Contains(t, fromArray, expected)
+	}
+}
+
+// renderModelTest renders the generated test of a model.
+func renderModelTest(t *testing.T, schema *config.SchemaModel) string {
+	t.Helper()
+	tmpl, err := templates.GetTemplates("")
+	require.NoError(t, err)
+
+	var out strings.Builder
+	require.NoError(t, tmpl.ExecuteTemplate(&out, "model-test.php.tmpl", templates.ModelTestData{
+		ClassName: schema.Name, VarName: strings.ToLower(schema.Name), Schema: schema,
+	}))
+	return out.String()
+}
+
+func TestModelTest_PatternProperties(t *testing.T) {
+	patterned := func(name string, required bool, example any) *config.Property {
+		return &config.Property{
+			Name:        name,
+			PHPType:     config.PHPType{Name: "string"},
+			OpenAPIType: &openapi3.Schema{Example: example},
+			Required:    required,
+			Constraints: &config.Constraints{Pattern: "^[a-z/']+$"},
+		}
+	}
+
+	schema := &config.SchemaModel{Name: "Tag", Properties: []*config.Property{
+		patterned("path", true, "a/b'c"),
+		patterned("slug", false, nil),
+	}}
+	test := renderModelTest(t, schema)
+	assert.Contains(t, test, `'path' => 'a/b\'c'`)
+	assert.NotContains(t, test, "'slug'")
+	assert.NotContains(t, test, "test_")
+	assert.NotContains(t, test, "markTestSkipped")
+
+	schema.Properties = append(schema.Properties, patterned("code", true, nil))
+	test = renderModelTest(t, schema)
+	assert.Contains(t, test,
+		"$this->markTestSkipped('No valid test value for property code, add an example to the spec');")
+	assert.NotContains(t, test, "fromArray")
+}
+
+func TestModelTest_RecursiveArrayMinItems(t *testing.T) {
+	minItems := uint64(1)
+	category := &config.SchemaModel{Name: "Category", Recursive: true}
+	categoryType := config.PHPType{Name: "Category", Model: category}
+	children := &config.Property{
+		Name:        "children",
+		PHPType:     config.PHPType{Name: "array", IsArray: true, Items: &categoryType},
+		Constraints: &config.Constraints{MinItems: &minItems},
+	}
+	category.Properties = []*config.Property{
+		{Name: "name", PHPType: config.PHPType{Name: "string"}, Required: true},
+		children,
+	}
+
+	test := renderModelTest(t, category)
+	assert.Contains(t, test, "'name' => 'test_name'")
+	assert.NotContains(t, test, "'children'")
+	assert.NotContains(t, test, "markTestSkipped")
+
+	children.Required = true
+	test = renderModelTest(t, category)
+	assert.Contains(t, test,
+		"$this->markTestSkipped('No valid test value for property children, add an example to the spec');")
+
+	children.PHPType.IsNullable = true
+	test = renderModelTest(t, category)
+	assert.Contains(t, test, "'children' => null")
+}
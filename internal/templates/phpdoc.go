@@ -0,0 +1,349 @@
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/floriscornel/piak/internal/php"
+)
+
+// isClass reports whether a type is a generated class or enum rather than a
+// builtin type or a number held as a BigDecimal.
+func isClass(phpType config.PHPType) bool {
+	return !phpType.IsArray && phpType.Numeric == "" && !php.IsBuiltinType(phpType.Name)
+}
+
+// needsConversion reports whether values of a type differ from their JSON data,
+// which is the case for classes, enums, numbers held as strings or BigDecimals whose
+// data may be ints or floats, and arrays containing any of them.
+func needsConversion(phpType config.PHPType) bool {
+	if phpType.Items != nil {
+		return needsConversion(*phpType.Items)
+	}
+	if phpType.Numeric != "" {
+		return phpType.DataType != phpType.Name
+	}
+	return isClass(phpType)
+}
+
+// throwsViolations reports whether hydrating a type can throw the violations of a
+// model, which is the case for models and arrays containing them.
+func throwsViolations(phpType config.PHPType) bool {
+	if phpType.Items != nil {
+		return throwsViolations(*phpType.Items)
+	}
+	return phpType.Model != nil && !phpType.IsEnum
+}
+
+// docType returns the PHPDoc type of a value, such as list<Pet>|null.
+func docType(phpType config.PHPType) string {
+	typ := phpType.DocComment
+	if typ == "" {
+		typ = phpType.Name
+	}
+	if phpType.IsNullable && typ != "mixed" {
+		typ += "|null"
+	}
+	return typ
+}
+
+// dataType returns the PHPDoc type of the JSON data of a value, such as
+// list<PetData>|null.
+func dataType(phpType config.PHPType) string {
+	typ := phpType.DataType
+	if typ == "" {
+		typ = "mixed"
+	}
+	if phpType.IsNullable && typ != "mixed" {
+		typ += "|null"
+	}
+	return typ
+}
+
+// nativeType returns the declared type of a value.
+func nativeType(phpType config.PHPType, imports *php.Imports) string {
+	if isClass(phpType) || phpType.Numeric == config.NumericBigDecimal {
+		phpType.Name = imports.Name(phpType.Name)
+	}
+	return formatPHPType(phpType)
+}
+
+// nativeDataType returns the declared type of the JSON data of a value: the backing
+// type of enums, array for classes and the numbers numeric types accept.
+func nativeDataType(phpType config.PHPType) string {
+	switch {
+	case phpType.Numeric != "":
+		phpType.Name = phpType.DataType
+	case phpType.IsEnum:
+		phpType.Name = "string"
+		if phpType.DataType == "int" {
+			phpType.Name = "int"
+		}
+	case isClass(phpType):
+		phpType.Name = arrayType
+	}
+	return formatPHPType(phpType)
+}
+
+// arrayType is the PHP type of lists, maps and JSON objects.
+const arrayType = "array"
+
+// itemVariable returns the closure parameter for the items of an array nested at
+// the given level: $item, $item2 and so on.
+func itemVariable(nesting int) string {
+	if nesting == 1 {
+		return "$item"
+	}
+	return fmt.Sprintf("$item%d", nesting)
+}
+
+// hydration holds what the hydrating expressions of a fromArray method need: the
+// imports of its class and, in recursive models, the depth argument passed on to
+// the fromArray of other recursive models.
+type hydration struct {
+	imports *php.Imports
+	depth   string
+}
+
+// value returns the expression converting JSON data of a type to its PHP value:
+// models are created with fromArray, enums from their backing value, numbers held as
+// strings or BigDecimals from any number and arrays item by item, at any nesting
+// level.
+func (h hydration) value(phpType config.PHPType, value string, nesting int) string {
+	var hydrated string
+	switch {
+	case !needsConversion(phpType):
+		return value
+	case phpType.Numeric == config.NumericBigDecimal:
+		hydrated = fmt.Sprintf("%s::of(%s)", h.imports.Name(phpType.Name), value)
+	case phpType.Numeric != "":
+		hydrated = "(string) " + value
+	case phpType.IsArray && throwsViolations(*phpType.Items):
+		// Violations of the items are reported under their key
+		hydrated = fmt.Sprintf("%s::map(%s, %s)",
+			h.imports.Name(ValidationExceptionClass), h.callable(*phpType.Items, nesting+1), value)
+		if !phpType.IsMap {
+			hydrated = "array_values(" + hydrated + ")"
+		}
+	case phpType.IsArray:
+		hydrated = fmt.Sprintf("array_map(%s, %s)", h.callable(*phpType.Items, nesting+1), value)
+	case phpType.IsEnum:
+		hydrated = fmt.Sprintf("%s::from(%s)", h.imports.Name(phpType.Name), value)
+	case h.tracksDepth(phpType):
+		hydrated = fmt.Sprintf("%s::fromArray(%s, %s)", h.imports.Name(phpType.Name), value, h.depth)
+	default:
+		hydrated = fmt.Sprintf("%s::fromArray(%s)", h.imports.Name(phpType.Name), value)
+	}
+
+	if phpType.IsNullable {
+		return fmt.Sprintf("%s === null ? null : %s", value, hydrated)
+	}
+	return hydrated
+}
+
+// callable returns the callable hydrating the items of an array.
+func (h hydration) callable(phpType config.PHPType, nesting int) string {
+	if isClass(phpType) && !phpType.IsNullable && !h.tracksDepth(phpType) {
+		method := "fromArray"
+		if phpType.IsEnum {
+			method = "from"
+		}
+		return fmt.Sprintf("%s::%s(...)", h.imports.Name(phpType.Name), method)
+	}
+
+	item := itemVariable(nesting)
+	return fmt.Sprintf("static fn (%s %s): %s => %s", nativeDataType(phpType), item,
+		nativeType(phpType, h.imports), h.value(phpType, item, nesting))
+}
+
+// tracksDepth reports whether hydrating a type passes on the depth argument.
+func (h hydration) tracksDepth(phpType config.PHPType) bool {
+	return h.depth != "" && phpType.Model != nil && phpType.Model.Recursive
+}
+
+// serializeValue returns the expression converting a PHP value of a type back to
+// its JSON data. Integers held as strings become ints again where PHP ints hold
+// them; decimals stay strings, as json_encode would write floats.
+func serializeValue(phpType config.PHPType, value string, imports *php.Imports, nesting int) string {
+	switch {
+	case !needsConversion(phpType) || phpType.Numeric == config.NumericDecimalString:
+		return value
+	case phpType.Numeric == config.NumericIntString:
+		return fmt.Sprintf("filter_var(%s, FILTER_VALIDATE_INT, FILTER_NULL_ON_FAILURE) ?? %s", value, value)
+	case phpType.Numeric == config.NumericBigDecimal && phpType.IsNullable:
+		return fmt.Sprintf("%s === null ? null : (string) %s", value, value)
+	case phpType.Numeric == config.NumericBigDecimal:
+		return "(string) " + value
+	case phpType.IsArray:
+		item := itemVariable(nesting + 1)
+		items := *phpType.Items
+		serializedItem := serializeValue(items, item, imports, nesting+1)
+		if serializedItem == item {
+			return value
+		}
+		serialized := fmt.Sprintf("array_map(static fn (%s %s): %s => %s, %s)", nativeType(items, imports), item,
+			nativeDataType(items), serializedItem, value)
+		if phpType.IsNullable {
+			return fmt.Sprintf("%s === null ? null : %s", value, serialized)
+		}
+		return serialized
+	}
+
+	operator := "->"
+	if phpType.IsNullable {
+		operator = "?->"
+	}
+	if phpType.IsEnum {
+		return value + operator + "value"
+	}
+	return value + operator + "toArray()"
+}
+
+// dataShape returns the PHPStan array shape of the data fromArray accepts, such as
+// array{name: string, tag?: string|null}.
+func dataShape(model *config.SchemaModel) string {
+	if len(model.Properties) == 0 {
+		return "array<string, mixed>"
+	}
+
+	items := make([]string, 0, len(model.Properties))
+	for _, prop := range model.Properties {
+		key := prop.Name
+		if !simpleKey.MatchString(key) {
+			key = php.StringLiteral(key)
+		}
+		if !prop.Required {
+			key += "?"
+		}
+		items = append(items, key+": "+dataType(prop.PHPType))
+	}
+	return "array{" + strings.Join(items, ", ") + "}"
+}
+
+// dataTypeTags returns the docblock tags, without @, declaring the type alias of a
+// model's data and importing the aliases of the models it references.
+func dataTypeTags(model *config.SchemaModel) []string {
+	if model.DataType == "" {
+		return nil
+	}
+
+	var tags []string
+	imported := make(map[string]bool)
+	var collect func(phpType config.PHPType)
+	collect = func(phpType config.PHPType) {
+		if phpType.Items != nil {
+			collect(*phpType.Items)
+		}
+		// References back into a cycle use array<string, mixed> instead of the alias
+		referenced := phpType.Model
+		if referenced == nil || referenced.IsEnum || referenced.DataType != phpType.DataType || referenced == model ||
+			imported[referenced.DataType] {
+			return
+		}
+		imported[referenced.DataType] = true
+		tags = append(tags, fmt.Sprintf("phpstan-import-type %s from %s", referenced.DataType, referenced.Name))
+	}
+	for _, prop := range model.Properties {
+		collect(prop.PHPType)
+	}
+
+	return append([]string{fmt.Sprintf("phpstan-type %s %s", model.DataType, dataShape(model))}, tags...)
+}
+
+// propertyDoc returns the docblock of a promoted property: its description,
+// example, format and constraints from the schema, a link to its external docs and
+// whether it is deprecated. The schema of a class or enum type documents the class
+// instead. It returns nil when there is nothing to document.
+func propertyDoc(prop *config.Property) *php.DocBlock {
+	if isClass(prop.PHPType) {
+		if prop.Deprecated {
+			return php.NewDocBlock().Tag("deprecated", "")
+		}
+		return nil
+	}
+
+	doc := php.NewDocBlock()
+	if prop.Description != "" {
+		doc.Lines = append(doc.Lines, strings.Split(php.MarkdownText(prop.Description), "\n")...)
+	}
+
+	var notes []string
+	if schema := prop.OpenAPIType; schema != nil {
+		if schema.Format != "" {
+			notes = append(notes, "Format: "+schema.Format+".")
+		}
+		if schema.Example != nil {
+			if example, err := json.Marshal(schema.Example); err == nil {
+				notes = append(notes, "Example: "+string(example))
+			}
+		}
+	}
+	if constraints := constraintNotes(prop.Constraints); len(constraints) > 0 {
+		notes = append(notes, "Constraints: "+strings.Join(constraints, ", ")+".")
+	}
+	if len(notes) > 0 {
+		if len(doc.Lines) > 0 {
+			doc.Lines = append(doc.Lines, "")
+		}
+		doc.Lines = append(doc.Lines, notes...)
+	}
+
+	if schema := prop.OpenAPIType; schema != nil && schema.ExternalDocs != nil && schema.ExternalDocs.URL != "" {
+		doc.Tag("see", strings.TrimSpace(schema.ExternalDocs.URL+" "+php.MarkdownText(schema.ExternalDocs.Description)))
+	}
+	if prop.Deprecated {
+		doc.Tag("deprecated", "")
+	}
+
+	if doc.IsEmpty() {
+		return nil
+	}
+	return doc
+}
+
+// constraintNotes describes constraints in schema terms, such as "maxLength 50".
+func constraintNotes(c *config.Constraints) []string {
+	if c == nil {
+		return nil
+	}
+
+	var notes []string
+	if c.MinLength != nil {
+		notes = append(notes, fmt.Sprintf("minLength %d", *c.MinLength))
+	}
+	if c.MaxLength != nil {
+		notes = append(notes, fmt.Sprintf("maxLength %d", *c.MaxLength))
+	}
+	if c.Pattern != "" {
+		notes = append(notes, "pattern "+c.Pattern)
+	}
+	if c.Minimum != nil {
+		if c.ExclusiveMinimum {
+			notes = append(notes, "exclusiveMinimum "+number(*c.Minimum))
+		} else {
+			notes = append(notes, "minimum "+number(*c.Minimum))
+		}
+	}
+	if c.Maximum != nil {
+		if c.ExclusiveMaximum {
+			notes = append(notes, "exclusiveMaximum "+number(*c.Maximum))
+		} else {
+			notes = append(notes, "maximum "+number(*c.Maximum))
+		}
+	}
+	if c.MultipleOf != nil {
+		notes = append(notes, "multipleOf "+number(*c.MultipleOf))
+	}
+	if c.MinItems != nil {
+		notes = append(notes, fmt.Sprintf("minItems %d", *c.MinItems))
+	}
+	if c.MaxItems != nil {
+		notes = append(notes, fmt.Sprintf("maxItems %d", *c.MaxItems))
+	}
+	if c.UniqueItems {
+		notes = append(notes, "uniqueItems")
+	}
+	return notes
+}
@@ -0,0 +1,120 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/floriscornel/piak/internal/php"
+)
+
+// serverConstructorParams are the arguments the named constructors of the client
+// take after the server variables, passed on to forServer().
+var serverConstructorParams = []*php.Param{
+	{Name: "defaultHeaders", Type: "array", Default: "[]"},
+	{Name: "httpClient", Type: "?ClientInterface", Default: "null"},
+	{Name: "requestFactory", Type: "?RequestFactoryInterface", Default: "null"},
+	{Name: "streamFactory", Type: "?StreamFactoryInterface", Default: "null"},
+	{Name: "retryPolicy", Type: "?RetryPolicy", Default: "null"},
+}
+
+// buildServerConstructor builds the named constructor creating a client for a server
+// of the spec, taking the values of its variables, which default to theirs.
+func buildServerConstructor(server *config.ServerModel) *php.Method {
+	doc := php.NewDocBlock(fmt.Sprintf("Create a client for the %s server, %s", server.Name, php.DocText(server.URL)))
+	if server.Description != "" {
+		doc.Lines = append(doc.Lines, "", php.MarkdownText(server.Description))
+	}
+	method := &php.Method{Name: server.ConstructorName, Doc: doc, Static: true, ReturnType: "self"}
+
+	var values []string
+	for _, variable := range server.Variables {
+		method.Params = append(method.Params, &php.Param{
+			Name: variable.ParamName, Type: "string", Default: php.StringLiteral(variable.Default),
+		})
+		docType := "string"
+		if len(variable.Enum) > 0 {
+			// PHPStan rejects values the variable does not allow
+			literals := make([]string, 0, len(variable.Enum))
+			for _, value := range variable.Enum {
+				literals = append(literals, php.StringLiteral(value))
+			}
+			docType = strings.Join(literals, "|")
+		}
+		doc.Tag("param", strings.TrimSpace(fmt.Sprintf("%s $%s %s",
+			docType, variable.ParamName, php.DocText(variable.Description))))
+		values = append(values, fmt.Sprintf("%s => $%s", php.StringLiteral(variable.Name), variable.ParamName))
+	}
+	args := []string{php.StringLiteral(server.Name), "[" + strings.Join(values, ", ") + "]"}
+	for _, param := range serverConstructorParams {
+		method.Params = append(method.Params, &php.Param{Name: param.Name, Type: param.Type, Default: param.Default})
+		args = append(args, "$"+param.Name)
+	}
+	doc.Tag("param", "array<string, string> $defaultHeaders Headers sent with every request")
+	if len(values) > 0 {
+		doc.Tag("throws", `\InvalidArgumentException When a value is not one the variable allows`)
+	}
+
+	method.Body = append(method.Body, &php.List{Open: "return self::forServer(", Items: php.Lines(args...), Close: ");"})
+	return method
+}
+
+// serverList returns the statement declaring the constant of the servers of the
+// spec, keyed by name, with the defaults and allowed values of their variables, or
+// nil when it declares none.
+func serverList(servers []*config.ServerModel) php.Stmt {
+	if len(servers) == 0 {
+		return nil
+	}
+	return &php.List{Open: "public const SERVERS = [", Items: serverItems(servers), Close: "];"}
+}
+
+// serverItems returns the items of an array of servers keyed by name.
+func serverItems(servers []*config.ServerModel) []php.Stmt {
+	items := make([]php.Stmt, 0, len(servers))
+	for _, server := range servers {
+		variables := &php.List{Open: "'variables' => [", Close: "]"}
+		for _, variable := range server.Variables {
+			enum := make([]string, 0, len(variable.Enum))
+			for _, value := range variable.Enum {
+				enum = append(enum, php.StringLiteral(value))
+			}
+			variables.Items = append(variables.Items, php.Line(fmt.Sprintf("%s => ['default' => %s, 'enum' => [%s]]",
+				php.StringLiteral(variable.Name), php.StringLiteral(variable.Default), strings.Join(enum, ", "))))
+		}
+		items = append(items, &php.List{
+			Open:  php.StringLiteral(server.Name) + " => [",
+			Items: []php.Stmt{php.Line("'url' => " + php.StringLiteral(server.URL)), variables},
+			Close: "]",
+		})
+	}
+	return items
+}
+
+// operationServerList returns the statement declaring the constant of the servers of
+// the operations declaring their own, keyed by operation ID, or nil when none does.
+func operationServerList(operations []*config.OperationModel) php.Stmt {
+	var items []php.Stmt
+	for _, op := range operations {
+		if len(op.Servers) > 0 {
+			items = append(items, &php.List{
+				Open:  php.StringLiteral(op.OperationID) + " => [",
+				Items: serverItems(op.Servers),
+				Close: "]",
+			})
+		}
+	}
+	if items == nil {
+		return nil
+	}
+	return &php.List{Open: "private const OPERATION_SERVERS = [", Items: items, Close: "];"}
+}
+
+// serverURL returns the URL of a server with its variables set to their defaults.
+func serverURL(server *config.ServerModel) string {
+	replacements := make([]string, 0, 2*len(server.Variables))
+	for _, variable := range server.Variables {
+		replacements = append(replacements, "{"+variable.Name+"}", variable.Default)
+	}
+	return strings.TrimRight(strings.NewReplacer(replacements...).Replace(server.URL), "/")
+}
@@ -0,0 +1,224 @@
+package templates
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/floriscornel/piak/internal/php"
+)
+
+// Test values are chosen to satisfy the constraints the generated models validate.
+// Where no value can be built to satisfy them, such as for a string with a pattern
+// but no example, a property gets none: it is left out of the test data when it is
+// optional, and the tests of the model are skipped when it is required.
+
+// missingTestValue returns the test value of a property no valid value can be built
+// for: null when it is nullable, and "" for none otherwise.
+func missingTestValue(prop *config.Property) string {
+	if prop.PHPType.IsNullable {
+		return "null"
+	}
+	return ""
+}
+
+// scalarExample returns the schema example of a scalar property as a PHP literal,
+// or "" when there is none.
+func scalarExample(prop *config.Property) string {
+	if prop.OpenAPIType == nil || prop.OpenAPIType.Example == nil {
+		return ""
+	}
+
+	switch prop.PHPType.Name {
+	case "string", "int", "float", "bool":
+	default:
+		return ""
+	}
+
+	example := prop.OpenAPIType.Example
+	switch example.(type) {
+	case string, bool, float64, int:
+	default:
+		return ""
+	}
+	if literal, err := php.Literal(example); err == nil {
+		return literal
+	}
+	return ""
+}
+
+// testString returns a string test value within the property's length limits, or
+// false when the property has a pattern, which a made up value would hardly match.
+func testString(prop *config.Property) (string, bool) {
+	value := []rune("test_" + strings.ToLower(prop.Name))
+	if c := prop.Constraints; c != nil {
+		if c.Pattern != "" {
+			return "", false
+		}
+		if c.MaxLength != nil && uint64(len(value)) > *c.MaxLength {
+			value = value[:*c.MaxLength]
+		}
+		for c.MinLength != nil && uint64(len(value)) < *c.MinLength {
+			value = append(value, 'x')
+		}
+	}
+	return string(value), true
+}
+
+// testNumber moves a numeric test value into the property's range and onto a
+// multiple of its multipleOf.
+func testNumber(prop *config.Property, value float64, integer bool) float64 {
+	c := prop.Constraints
+	if c == nil {
+		return value
+	}
+
+	step := 1.0
+	if !integer {
+		step = 0.01
+	}
+	if c.MultipleOf != nil {
+		step = *c.MultipleOf
+	}
+
+	low, high := math.Inf(-1), math.Inf(1)
+	if c.Minimum != nil {
+		low = *c.Minimum
+		if c.ExclusiveMinimum {
+			low += step
+		}
+	}
+	if c.Maximum != nil {
+		high = *c.Maximum
+		if c.ExclusiveMaximum {
+			high -= step
+		}
+	}
+
+	value = math.Max(low, math.Min(high, value))
+	if c.MultipleOf != nil {
+		value = math.Ceil(value/step) * step
+		if value > high {
+			value -= step
+		}
+	}
+	if integer {
+		value = math.Ceil(value)
+	}
+	// Drop float noise from the arithmetic above
+	return math.Round(value*1e6) / 1e6
+}
+
+// numericTestValue formats a test number as the numeric string of a number held as
+// a string or BigDecimal, without the exponent or trailing .0 of a PHP float.
+func numericTestValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// visiting holds the models whose test data is being built, so that data for a
+// model referring back to one of them ends instead of nesting forever.
+type visiting map[*config.SchemaModel]bool
+
+// with returns the path extended with model.
+func (v visiting) with(model *config.SchemaModel) visiting {
+	path := make(visiting, len(v)+1)
+	for visited := range v {
+		path[visited] = true
+	}
+	path[model] = true
+	return path
+}
+
+// nestedTestData returns the minimal data of a model nested in other test data, on
+// a single line, or "" when the model is already on path or one of its required
+// properties has no test value.
+func nestedTestData(model *config.SchemaModel, path visiting) string {
+	if path[model] {
+		return ""
+	}
+
+	path = path.with(model)
+	var properties []string
+	for _, prop := range minimalTestPropertiesOf(model, path) {
+		value := propertyTestValue(prop, path)
+		if value == "" {
+			return ""
+		}
+		properties = append(properties, fmt.Sprintf("%s => %s", php.StringLiteral(prop.Name), value))
+	}
+	return "[" + strings.Join(properties, ", ") + "]"
+}
+
+// testArray returns an array test value with as many distinct items as the
+// property needs, and one for arrays of models and enums so hydrating them is
+// tested too. It returns "" when the property needs items its item type has no
+// test value for.
+func testArray(prop *config.Property, path visiting) string {
+	items, ok := testArrayItems(prop, path)
+	if !ok {
+		return ""
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// testArrayItems returns the items of testArray, with their keys for maps. When the
+// item type has no test value, it returns none, and false if the property needs
+// some.
+func testArrayItems(prop *config.Property, path visiting) ([]string, bool) {
+	var minItems uint64
+	if prop.Constraints != nil && prop.Constraints.MinItems != nil {
+		minItems = *prop.Constraints.MinItems
+	}
+	itemType := prop.PHPType.Items
+	if itemType == nil {
+		return nil, minItems == 0
+	}
+
+	n := minItems
+	if n == 0 && needsConversion(*itemType) {
+		n = 1
+	}
+
+	items := make([]string, 0, n)
+	for i := 1; uint64(len(items)) < n; i++ {
+		item := testItemValue(*itemType, i, path)
+		if item == "" {
+			return nil, minItems == 0
+		}
+		if prop.PHPType.IsMap {
+			item = fmt.Sprintf("'key%d' => %s", i, item)
+		}
+		items = append(items, item)
+	}
+	return items, true
+}
+
+// testItemValue returns the i-th distinct test value of an array item type, or ""
+// when there is none.
+func testItemValue(itemType config.PHPType, i int, path visiting) string {
+	switch {
+	case itemType.IsEnum:
+		if itemType.Model == nil || len(itemType.Model.EnumCases) == 0 {
+			return ""
+		}
+		cases := itemType.Model.EnumCases
+		value, _ := php.Literal(cases[(i-1)%len(cases)].Value)
+		return value
+	case itemType.Model != nil:
+		return nestedTestData(itemType.Model, path)
+	case itemType.IsArray:
+		return "[]"
+	case itemType.Numeric != "":
+		return php.StringLiteral(strconv.Itoa(i))
+	}
+
+	switch itemType.Name {
+	case "int", "float":
+		return fmt.Sprint(i)
+	case "string":
+		return php.StringLiteral(fmt.Sprintf("item%d", i))
+	}
+	return ""
+}
@@ -0,0 +1,315 @@
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/floriscornel/piak/internal/config"
+	"github.com/floriscornel/piak/internal/php"
+)
+
+// ValidationExceptionClass is the name of the generated exception listing every
+// constraint violation found in a model.
+const ValidationExceptionClass = "ValidationException"
+
+// multipleOfTolerance absorbs float rounding when checking multipleOf on numbers.
+const multipleOfTolerance = "1.0E-9"
+
+// simpleKey matches property names that can follow a dot in a JSON path.
+var simpleKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// jsonPath returns the JSON path of a top-level property, e.g. $.name or $["first-name"].
+func jsonPath(name string) string {
+	if simpleKey.MatchString(name) {
+		return "$." + name
+	}
+	return "$[" + php.JSONString(name) + "]"
+}
+
+// count formats a number of things, e.g. "1 item" or "3 items".
+func count(n uint64, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// addViolation returns the statement recording a violation of a property.
+func addViolation(prop *config.Property, message string) php.Stmt {
+	return php.Line(fmt.Sprintf("$violations[] = ['path' => %s, 'message' => %s];",
+		php.StringLiteral(jsonPath(prop.Name)), php.StringLiteral(message)))
+}
+
+// throwViolations returns the statement throwing the collected violations, if any.
+func throwViolations(imports *php.Imports) php.Stmt {
+	return php.If("$violations !== []",
+		php.Line(fmt.Sprintf("throw new %s($violations);", imports.Name(ValidationExceptionClass))))
+}
+
+// buildValidateMethod builds a method returning the violations of the schema
+// constraints by the values of the properties having any, which it takes in order.
+// Values may be Undefined, which fromArray passes for missing or invalid data so
+// it reports these violations with its own. It returns nil when no property has
+// constraints.
+func buildValidateMethod(model *config.SchemaModel, imports *php.Imports) *php.Method {
+	method := &php.Method{
+		Name:       "validate",
+		Doc:        php.NewDocBlock("Check property values against the schema constraints, skipping undefined ones"),
+		Visibility: php.Private,
+		Static:     true,
+		ReturnType: arrayType,
+	}
+	var checks []php.Stmt
+	for _, prop := range model.Properties {
+		propChecks := constraintChecks(prop, imports)
+		if len(propChecks) == 0 {
+			continue
+		}
+		checks = append(checks, propChecks...)
+
+		param := &php.Param{Name: localName(prop), Type: validatedType(prop, imports)}
+		if prop.PHPType.IsArray {
+			// The checks only count the items, which fromArray passes unhydrated
+			method.Doc.Tag("param", strings.Replace(param.Type, arrayType, "array<mixed>", 1)+" $"+param.Name)
+		}
+		method.Params = append(method.Params, param)
+	}
+	if len(checks) == 0 {
+		return nil
+	}
+
+	method.Doc.Tag("return", "list<array{path: string, message: string}>")
+	method.Body = append([]php.Stmt{php.Line("$violations = [];")}, checks...)
+	method.Body = append(method.Body, php.BlankLine{}, php.Line("return $violations;"))
+	return method
+}
+
+// validatedType returns the declared type of a property taken by the validate
+// method, which also accepts the Undefined sentinel.
+func validatedType(prop *config.Property, imports *php.Imports) string {
+	undefinable := *prop
+	undefinable.Required, undefinable.Default = false, nil
+	return propertyType(&undefinable, imports)
+}
+
+// validateCall returns the statement calling the validate method with the values
+// of the properties it checks, given by value, and ending with suffix, at the
+// given indentation level of a method body.
+func validateCall(
+	model *config.SchemaModel, imports *php.Imports, open, suffix string, level int, value func(*config.Property) string,
+) php.Stmt {
+	var args []string
+	for _, prop := range model.Properties {
+		if len(constraintChecks(prop, imports)) > 0 {
+			args = append(args, value(prop))
+		}
+	}
+	if line := open + "self::validate(" + strings.Join(args, ", ") + ")" + suffix; len(line) <= 120-4*level {
+		return php.Line(line)
+	}
+	return &php.List{Open: open + "self::validate(", Items: php.Lines(args...), Close: ")" + suffix}
+}
+
+// reservedLocals are the variables of the validate and fromArray methods that the
+// variables named after properties must not reuse.
+var reservedLocals = map[string]bool{"data": true, "depth": true, "violations": true, "exception": true}
+
+// localName returns the name of the variable holding the value of a property in
+// the validate and fromArray methods.
+func localName(prop *config.Property) string {
+	name := propertyName(prop)
+	if reservedLocals[name] {
+		return name + "Value"
+	}
+	return name
+}
+
+// constraintChecks returns the checks for a property's constraints, on the variable
+// holding its value. Checks only apply to values of their type: they are left out
+// for other declared types, and guarded by a type check for mixed properties. Null
+// and Undefined are skipped.
+func constraintChecks(prop *config.Property, imports *php.Imports) []php.Stmt {
+	value := "$" + localName(prop)
+	var checks []php.Stmt
+	check := func(guard, condition, message string) {
+		if guard != "" {
+			checks = append(checks, php.If(guard+" && "+condition, addViolation(prop, message)))
+		}
+	}
+
+	if c := prop.Constraints; c != nil {
+		isString := typeGuard(prop, imports, fmt.Sprintf("is_string(%s)", value), "string")
+		if c.MinLength != nil {
+			check(isString, fmt.Sprintf("mb_strlen(%s) < %d", value, *c.MinLength),
+				"must be at least "+count(*c.MinLength, "character")+" long")
+		}
+		if c.MaxLength != nil {
+			check(isString, fmt.Sprintf("mb_strlen(%s) > %d", value, *c.MaxLength),
+				"must be at most "+count(*c.MaxLength, "character")+" long")
+		}
+		if c.Pattern != "" {
+			check(isString, fmt.Sprintf("preg_match(%s, %s) !== 1", php.StringLiteral(pcrePattern(c.Pattern)), value),
+				"must match the pattern "+c.Pattern)
+		}
+
+		isNumber := typeGuard(prop, imports, fmt.Sprintf("(is_int(%s) || is_float(%s))", value, value), "int", "float")
+		compare := numberComparison(prop.PHPType, value)
+		if prop.PHPType.Numeric != "" {
+			isNumber = setGuard(prop, imports)
+		}
+		if c.Minimum != nil {
+			limit := number(*c.Minimum)
+			if c.ExclusiveMinimum {
+				check(isNumber, compare("<=", limit), "must be greater than "+limit)
+			} else {
+				check(isNumber, compare("<", limit), "must be greater than or equal to "+limit)
+			}
+		}
+		if c.Maximum != nil {
+			limit := number(*c.Maximum)
+			if c.ExclusiveMaximum {
+				check(isNumber, compare(">=", limit), "must be less than "+limit)
+			} else {
+				check(isNumber, compare(">", limit), "must be less than or equal to "+limit)
+			}
+		}
+		if c.MultipleOf != nil {
+			divisor := number(*c.MultipleOf)
+			dividend := value
+			if prop.PHPType.Numeric != "" {
+				dividend = "(float) " + value
+			}
+			condition := fmt.Sprintf("abs(%s / %s - round(%s / %s)) > %s",
+				dividend, divisor, dividend, divisor, multipleOfTolerance)
+			switch {
+			case prop.PHPType.Numeric == config.NumericBigDecimal:
+				condition = fmt.Sprintf("!%s->remainder(%s)->isZero()", value, php.StringLiteral(divisor))
+			case prop.PHPType.Name == "int" && !strings.ContainsAny(divisor, ".eE"):
+				condition = fmt.Sprintf("%s %% %s !== 0", value, divisor)
+			}
+			check(isNumber, condition, "must be a multiple of "+divisor)
+		}
+
+		isArray := typeGuard(prop, imports, fmt.Sprintf("is_array(%s)", value), arrayType)
+		if c.MinItems != nil {
+			check(isArray, fmt.Sprintf("count(%s) < %d", value, *c.MinItems),
+				"must contain at least "+count(*c.MinItems, "item"))
+		}
+		if c.MaxItems != nil {
+			check(isArray, fmt.Sprintf("count(%s) > %d", value, *c.MaxItems),
+				"must contain at most "+count(*c.MaxItems, "item"))
+		}
+		if c.UniqueItems {
+			check(isArray, fmt.Sprintf("count(%s) !== count(array_unique(%s, SORT_REGULAR))", value, value),
+				"must contain unique items")
+		}
+	}
+
+	// Generated enums are checked by fromArray and their type; inline enums here,
+	// except on BigDecimals, which in_array cannot compare
+	if len(prop.EnumValues) > 0 && !prop.PHPType.IsEnum && prop.PHPType.Numeric != config.NumericBigDecimal {
+		condition := fmt.Sprintf("!in_array(%s, [%s], true)", value, enumList(prop.EnumValues))
+		message := "must be one of " + enumMessage(prop.EnumValues)
+		check(setGuard(prop, imports), condition, message)
+	}
+
+	return checks
+}
+
+// numberComparison returns a function building the condition comparing a number
+// with a limit, such as $this->age < 0. BigDecimals are compared exactly with their
+// methods; numeric strings compare as numbers in PHP.
+func numberComparison(phpType config.PHPType, value string) func(operator, limit string) string {
+	methods := map[string]string{
+		"<":  "isLessThan",
+		"<=": "isLessThanOrEqualTo",
+		">":  "isGreaterThan",
+		">=": "isGreaterThanOrEqualTo",
+	}
+	return func(operator, limit string) string {
+		if phpType.Numeric == config.NumericBigDecimal {
+			return fmt.Sprintf("%s->%s(%s)", value, methods[operator], php.StringLiteral(limit))
+		}
+		return fmt.Sprintf("%s %s %s", value, operator, limit)
+	}
+}
+
+// typeGuard returns the guard of a check applying to the given types: isType for
+// mixed properties, the guard skipping null and Undefined for properties declared
+// with one of the types, or "" when the check does not apply to the property.
+func typeGuard(prop *config.Property, imports *php.Imports, isType string, types ...string) string {
+	name := prop.PHPType.Name
+	if prop.PHPType.IsArray {
+		name = arrayType
+	}
+	if name == "mixed" {
+		return isType
+	}
+	for _, typ := range types {
+		if name == typ {
+			return setGuard(prop, imports)
+		}
+	}
+	return ""
+}
+
+// setGuard returns the guard skipping null and Undefined values of a property.
+func setGuard(prop *config.Property, imports *php.Imports) string {
+	value := "$" + localName(prop)
+	guards := []string{fmt.Sprintf("!%s instanceof %s", value, imports.Name(UndefinedClass))}
+	if prop.PHPType.IsNullable && prop.PHPType.Name != "mixed" {
+		guards = append(guards, value+" !== null")
+	}
+	return strings.Join(guards, " && ")
+}
+
+// enumList returns enum values as a comma-separated list of PHP literals.
+func enumList(values []interface{}) string {
+	literals := make([]string, 0, len(values))
+	for _, value := range values {
+		if literal, err := php.Literal(value); err == nil {
+			literals = append(literals, literal)
+		}
+	}
+	return strings.Join(literals, ", ")
+}
+
+// enumMessage returns enum values as a comma-separated list of JSON values, for messages.
+func enumMessage(values []interface{}) string {
+	items := make([]string, 0, len(values))
+	for _, value := range values {
+		if encoded, err := json.Marshal(value); err == nil {
+			items = append(items, string(encoded))
+		}
+	}
+	return strings.Join(items, ", ")
+}
+
+// number formats a constraint value as a PHP number.
+func number(f float64) string {
+	literal, err := php.Literal(f)
+	if err != nil {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return literal
+}
+
+// pcrePattern turns an ECMA-262 pattern from a schema into a PCRE pattern for
+// preg_match, escaping the delimiter and matching on code points.
+func pcrePattern(pattern string) string {
+	var b strings.Builder
+	b.WriteByte('/')
+	escaped := false
+	for _, r := range pattern {
+		if r == '/' && !escaped {
+			b.WriteByte('\\')
+		}
+		escaped = r == '\\' && !escaped
+		b.WriteRune(r)
+	}
+	b.WriteString("/u")
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package watcher
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Watcher polls a set of files and directories and reports changes once they have settled.
+//
+// Polling is used instead of filesystem notifications because editors commonly save
+// by replacing files, which makes notification-based watches lose track of them.
+type Watcher struct {
+	interval time.Duration
+	debounce time.Duration
+	paths    func() []string
+}
+
+// fileState is the part of a file's metadata used to detect changes.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// snapshot maps every watched file to its state at the time of polling.
+type snapshot map[string]fileState
+
+// New creates a new Watcher. The paths function is called on every poll so the
+// watched set can grow or shrink, e.g. when a spec starts referencing a new file.
+func New(interval, debounce time.Duration, paths func() []string) *Watcher {
+	return &Watcher{
+		interval: interval,
+		debounce: debounce,
+		paths:    paths,
+	}
+}
+
+// Run polls until the context is cancelled. After a change is detected it waits
+// until no further changes happen for the debounce duration, then calls onChange
+// with the sorted list of files that were added, modified or removed.
+func (w *Watcher) Run(ctx context.Context, onChange func(changed []string)) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	current := w.snapshot()
+	var pending map[string]bool
+	var lastChange time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			next := w.snapshot()
+			if changed := diff(current, next); len(changed) > 0 {
+				if pending == nil {
+					pending = make(map[string]bool)
+				}
+				for _, path := range changed {
+					pending[path] = true
+				}
+				lastChange = now
+			}
+			current = next
+
+			if pending != nil && now.Sub(lastChange) >= w.debounce {
+				onChange(sortedKeys(pending))
+				pending = nil
+				// Pick up whatever onChange did to the watched set
+				current = w.snapshot()
+			}
+		}
+	}
+}
+
+// snapshot records the state of every watched file, expanding directories recursively.
+func (w *Watcher) snapshot() snapshot {
+	snap := make(snapshot)
+	for _, root := range w.paths() {
+		// Missing paths are simply absent from the snapshot, so their creation is a change
+		_ = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			// Unreadable entries are skipped rather than aborting the poll
+			if err == nil && !entry.IsDir() {
+				if info, infoErr := entry.Info(); infoErr == nil {
+					snap[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+				}
+			}
+			return nil
+		})
+	}
+	return snap
+}
+
+// diff returns the files that differ between two snapshots.
+func diff(before, after snapshot) []string {
+	var changed []string
+	for path, state := range after {
+		if old, ok := before[path]; !ok || !old.modTime.Equal(state.modTime) || old.size != state.size {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	return changed
+}
+
+// sortedKeys returns the keys of a set in sorted order.
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,74 @@
+package watcher_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/floriscornel/piak/internal/watcher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher_ReportsChangedFilesAfterDebounce(t *testing.T) {
+	tmpDir := t.TempDir()
+	specFile := filepath.Join(tmpDir, "api.yaml")
+	require.NoError(t, os.WriteFile(specFile, []byte("openapi: 3.0.0\n"), 0644))
+
+	templatesDir := filepath.Join(tmpDir, "templates")
+	require.NoError(t, os.MkdirAll(templatesDir, 0755))
+
+	// The paths are asked for by the initial snapshot and again on every poll, so the
+	// initial snapshot is complete once the first poll asks for them
+	polled := make(chan struct{})
+	var calls atomic.Int32
+	w := watcher.New(10*time.Millisecond, 50*time.Millisecond, func() []string {
+		if calls.Add(1) == 2 {
+			close(polled)
+		}
+		return []string{specFile, templatesDir}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	changes := make(chan []string, 1)
+	go func() {
+		_ = w.Run(ctx, func(changed []string) {
+			changes <- changed
+			cancel()
+		})
+	}()
+
+	select {
+	case <-polled:
+	case <-ctx.Done():
+		t.Fatal("expected the watcher to poll")
+	}
+
+	newTemplate := filepath.Join(templatesDir, "model.php.tmpl")
+	require.NoError(t, os.WriteFile(specFile, []byte("openapi: 3.0.3\ninfo: {}\n"), 0644))
+	require.NoError(t, os.WriteFile(newTemplate, []byte("<?php\n"), 0644))
+
+	select {
+	case changed := <-changes:
+		assert.ElementsMatch(t, []string{specFile, newTemplate}, changed)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a change notification")
+	}
+}
+
+func TestWatcher_StopsWhenContextIsCancelled(t *testing.T) {
+	w := watcher.New(10*time.Millisecond, 10*time.Millisecond, func() []string { return nil })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := w.Run(ctx, func(_ []string) {
+		t.Fatal("onChange should not be called")
+	})
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -43,8 +43,12 @@ func getTestCases() []TestCase {
 				"src/Order.php",
 				"src/Tag.php",
 				"src/ApiResponse.php",
-				"src/Error.php",
+				"src/ErrorModel.php",
 				"src/ApiClient.php",
+				"src/CurlHttpClient.php",
+				"src/ApiException.php",
+				"src/NotFoundException.php",
+				"src/ProblemDetails.php",
 				"tests/PetTest.php",
 				"tests/UserTest.php",
 				"tests/ApiClientTest.php",